@@ -0,0 +1,247 @@
+package formatter
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"sw/json-parser/jsonparser"
+	"sw/json-parser/lexer"
+	"sw/json-parser/parser"
+	"sw/json-parser/token"
+)
+
+type FormatterOptions struct {
+	// Indent is written once per nesting level in front of every line.
+	Indent string
+	// MaxLineWidth keeps arrays and objects on a single line as long as the
+	// line fits into the given width. With 0 every non-empty array and object
+	// is spread over multiple lines.
+	MaxLineWidth int
+	// SortKeys writes object members sorted by their keys instead of in the
+	// order of the input.
+	SortKeys bool
+	// Parser is used to read the input, Strict and Limits apply the same way
+	// they do for the parser. Like for the parser, a trailing comma is only
+	// rejected in strict mode.
+	Parser parser.ParserOptions
+}
+
+// node is a value of the formatted document. Containers keep their values in
+// children, scalars keep the token they were read from, so that numbers are
+// written exactly as they were in the input.
+type node struct {
+	token    token.Token
+	key      string
+	children []*node
+}
+
+func (node *node) isContainer() bool {
+	return node.token.Type == token.LBRACE || node.token.Type == token.LSQUARE_BRACE
+}
+
+// Format pretty-prints the input. The output ends with a newline.
+func Format(input string, options FormatterOptions) (string, parser.ParserErrors) {
+	root, errors := buildTree(input, options.Parser)
+	if errors != nil {
+		return "", errors
+	}
+
+	printer := prettyPrinter{options: options}
+	printer.writePretty(root, 0, 0)
+	printer.builder.WriteByte('\n')
+
+	return printer.builder.String(), nil
+}
+
+// Minify writes the input without any insignificant whitespace. Options are
+// optional, only the first one passed is used to read the input.
+func Minify(input string, options ...parser.ParserOptions) (string, parser.ParserErrors) {
+	parserOptions := parser.ParserOptions{}
+	if len(options) > 0 {
+		parserOptions = options[0]
+	}
+
+	root, errors := buildTree(input, parserOptions)
+	if errors != nil {
+		return "", errors
+	}
+
+	printer := prettyPrinter{}
+	printer.writeFlat(root, false)
+
+	return printer.builder.String(), nil
+}
+
+// FormatResult pretty-prints an already parsed document. The parser options
+// are not used, the document was already checked when it was parsed.
+func FormatResult(result *parser.ParserResult, options FormatterOptions) (string, error) {
+	encoded, err := jsonparser.Marshal(result.Value)
+	if err != nil {
+		return "", err
+	}
+	options.Parser = parser.ParserOptions{MaxDepth: -1}
+
+	formatted, errors := Format(string(encoded), options)
+	if errors != nil {
+		return "", errors
+	}
+
+	return formatted, nil
+}
+
+type prettyPrinter struct {
+	options FormatterOptions
+	builder strings.Builder
+}
+
+func (printer *prettyPrinter) writePretty(node *node, depth int, column int) {
+	if node.isContainer() == false || len(node.children) == 0 {
+		printer.writeFlat(node, true)
+
+		return
+	}
+
+	if printer.options.MaxLineWidth > 0 {
+		flat := printer.flatString(node)
+		// leave room for a trailing comma
+		if column+utf8.RuneCountInString(flat)+1 <= printer.options.MaxLineWidth {
+			printer.builder.WriteString(flat)
+
+			return
+		}
+	}
+
+	opening, closing := "[", "]"
+	if node.token.Type == token.LBRACE {
+		opening, closing = "{", "}"
+	}
+
+	printer.builder.WriteString(opening)
+	for idx, child := range printer.children(node) {
+		if idx > 0 {
+			printer.builder.WriteByte(',')
+		}
+
+		printer.builder.WriteByte('\n')
+		indentation := strings.Repeat(printer.options.Indent, depth+1)
+		printer.builder.WriteString(indentation)
+
+		childColumn := utf8.RuneCountInString(indentation)
+		if node.token.Type == token.LBRACE {
+			key := jsonparser.QuoteString(child.key) + ": "
+			printer.builder.WriteString(key)
+			childColumn += utf8.RuneCountInString(key)
+		}
+
+		printer.writePretty(child, depth+1, childColumn)
+	}
+
+	printer.builder.WriteByte('\n')
+	printer.builder.WriteString(strings.Repeat(printer.options.Indent, depth))
+	printer.builder.WriteString(closing)
+}
+
+func (printer *prettyPrinter) flatString(node *node) string {
+	flatPrinter := prettyPrinter{options: printer.options}
+	flatPrinter.writeFlat(node, true)
+
+	return flatPrinter.builder.String()
+}
+
+// writeFlat writes the node on a single line, with a space after every
+// separator when spaced is set.
+func (printer *prettyPrinter) writeFlat(node *node, spaced bool) {
+	switch node.token.Type {
+	case token.STRING:
+		printer.builder.WriteString(jsonparser.QuoteString(node.token.Literal))
+	case token.LSQUARE_BRACE:
+		printer.builder.WriteByte('[')
+		for idx, child := range node.children {
+			if idx > 0 {
+				printer.writeSeparator(",", spaced)
+			}
+			printer.writeFlat(child, spaced)
+		}
+		printer.builder.WriteByte(']')
+	case token.LBRACE:
+		printer.builder.WriteByte('{')
+		for idx, child := range printer.children(node) {
+			if idx > 0 {
+				printer.writeSeparator(",", spaced)
+			}
+			printer.builder.WriteString(jsonparser.QuoteString(child.key))
+			printer.writeSeparator(":", spaced)
+			printer.writeFlat(child, spaced)
+		}
+		printer.builder.WriteByte('}')
+	default:
+		printer.builder.WriteString(node.token.Literal)
+	}
+}
+
+func (printer *prettyPrinter) writeSeparator(separator string, spaced bool) {
+	printer.builder.WriteString(separator)
+	if spaced {
+		printer.builder.WriteByte(' ')
+	}
+}
+
+func (printer *prettyPrinter) children(container *node) []*node {
+	if printer.options.SortKeys == false || container.token.Type != token.LBRACE {
+		return container.children
+	}
+
+	sorted := slices.Clone(container.children)
+	slices.SortStableFunc(sorted, func(a *node, b *node) int { return strings.Compare(a.key, b.key) })
+
+	return sorted
+}
+
+// buildTree reads the input with a parser.Decoder, so that the grammar, the
+// errors and the options are the same as the ones of the parser.
+func buildTree(input string, options parser.ParserOptions) (*node, parser.ParserErrors) {
+	options.AllowTrailingData = false
+	decoder := parser.NewDecoder(lexer.New(input), options)
+
+	var root *node
+	// the arrays and objects that are not closed yet
+	var containers []*node
+	key := ""
+
+	for {
+		event, err := decoder.Token()
+		if err == io.EOF {
+			return root, nil
+		}
+
+		if err != nil {
+			return nil, err.(parser.ParserErrors)
+		}
+
+		switch event.Type {
+		case parser.Key:
+			key = event.Key
+
+			continue
+		case parser.EndObject, parser.EndArray:
+			containers = containers[:len(containers)-1]
+
+			continue
+		}
+
+		current := &node{token: event.Token, key: key}
+		key = ""
+		if len(containers) == 0 {
+			root = current
+		} else {
+			parent := containers[len(containers)-1]
+			parent.children = append(parent.children, current)
+		}
+
+		if event.Type == parser.BeginObject || event.Type == parser.BeginArray {
+			containers = append(containers, current)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package formatter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"sw/json-parser/jsonparser"
+	"sw/json-parser/parser"
+)
+
+func TestFormatIndentsEveryContainer(t *testing.T) {
+	input := `{"name":"Joe","age":88,"salary":1.50e3,"orders":[{"id":12,"tags":[]},{"id":13,"tags":["a","b"]}],"address":{}}`
+
+	expected := `{
+  "name": "Joe",
+  "age": 88,
+  "salary": 1.50e3,
+  "orders": [
+    {
+      "id": 12,
+      "tags": []
+    },
+    {
+      "id": 13,
+      "tags": [
+        "a",
+        "b"
+      ]
+    }
+  ],
+  "address": {}
+}
+`
+
+	formatted, err := Format(input, FormatterOptions{Indent: "  "})
+	if err != nil {
+		t.Fatalf("Format returned an error. Error: %q", err)
+	}
+
+	if formatted != expected {
+		t.Fatalf("Unexpected output.\nExpected:\n%s\nbut got:\n%s", expected, formatted)
+	}
+}
+
+func TestFormatKeepsShortContainersOnOneLine(t *testing.T) {
+	input := `{"zip": null, "orders": [{"tags": ["a", "b"], "id": 12}, {"tags": [], "id": 13}], "name": "Joe"}`
+
+	tests := []struct {
+		options  FormatterOptions
+		expected string
+	}{
+		{
+			FormatterOptions{Indent: "\t", MaxLineWidth: 40},
+			"{\n\t\"zip\": null,\n\t\"orders\": [\n\t\t{\"tags\": [\"a\", \"b\"], \"id\": 12},\n\t\t{\"tags\": [], \"id\": 13}\n\t],\n\t\"name\": \"Joe\"\n}\n",
+		},
+		{
+			FormatterOptions{Indent: "\t", MaxLineWidth: 40, SortKeys: true},
+			"{\n\t\"name\": \"Joe\",\n\t\"orders\": [\n\t\t{\"id\": 12, \"tags\": [\"a\", \"b\"]},\n\t\t{\"id\": 13, \"tags\": []}\n\t],\n\t\"zip\": null\n}\n",
+		},
+		{
+			FormatterOptions{Indent: "\t", MaxLineWidth: 200, SortKeys: true},
+			"{\"name\": \"Joe\", \"orders\": [{\"id\": 12, \"tags\": [\"a\", \"b\"]}, {\"id\": 13, \"tags\": []}], \"zip\": null}\n",
+		},
+	}
+
+	for i, test := range tests {
+		formatted, err := Format(input, test.options)
+		if err != nil {
+			t.Fatalf("tests[%d] - Format returned an error. Error: %q", i, err)
+		}
+
+		if formatted != test.expected {
+			t.Fatalf("tests[%d] - unexpected output.\nExpected:\n%q\nbut got:\n%q", i, test.expected, formatted)
+		}
+	}
+}
+
+func TestMinifyPreservesLiterals(t *testing.T) {
+	input := "{\n  \"price\" : 1.10,\n  \"big\": 12345678901234567890123,\n  \"exp\": -2.5E-3,\n  \"text\": \"tab\\there \\u00e9\",\n  \"list\": [ true , false , null, ],\n}"
+
+	minified, err := Minify(input)
+	if err != nil {
+		t.Fatalf("Minify returned an error. Error: %q", err)
+	}
+
+	expected := `{"price":1.10,"big":12345678901234567890123,"exp":-2.5E-3,"text":"tab\there é","list":[true,false,null]}`
+	if minified != expected {
+		t.Fatalf("Unexpected output.\nExpected=%s\nbut got= %s", expected, minified)
+	}
+
+	if _, errors := jsonparser.Parse(minified); errors != nil {
+		t.Fatalf("Minified output is not valid JSON: %q", errors)
+	}
+}
+
+func TestFormatReportsSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedCode parser.ErrorCode
+	}{
+		{`{"a": 1 "b": 2}`, parser.CodeMissingComma},
+		{`{a: 1}`, parser.CodeInvalidKey},
+		{`{"a" 1}`, parser.CodeMissingColon},
+		{`[1, 2`, parser.CodeUnclosedArray},
+		{`{"a": [1]`, parser.CodeUnclosedObject},
+		{`[1] 2`, parser.CodeTrailingData},
+		{`[007]`, parser.CodeInvalidNumber},
+		{`["\x"]`, parser.CodeInvalidEscape},
+		{``, parser.CodeUnexpectedEOF},
+		{`[:]`, parser.CodeUnexpectedToken},
+	}
+
+	for i, test := range tests {
+		_, err := Format(test.input, FormatterOptions{Indent: "  "})
+		if len(err) != 1 {
+			t.Fatalf("tests[%d] - expected exactly one error, but got %q", i, err)
+		}
+
+		if errors.Is(err[0], test.expectedCode) == false {
+			t.Fatalf("tests[%d] - expected %s, but got %q", i, test.expectedCode, err[0])
+		}
+	}
+}
+
+func TestFormatResult(t *testing.T) {
+	result, errors := jsonparser.Parse(`{"b": [1, 2.5], "a": "x"}`)
+	if errors != nil {
+		t.Fatalf("Parse returned an error. Error: %q", errors)
+	}
+
+	formatted, err := FormatResult(result, FormatterOptions{Indent: "  ", MaxLineWidth: 80})
+	if err != nil {
+		t.Fatalf("FormatResult returned an error. Error: %q", err)
+	}
+
+	expected := "{\"a\": \"x\", \"b\": [1, 2.5]}\n"
+	if formatted != expected {
+		t.Fatalf("Unexpected output.\nExpected=%q\nbut got= %q", expected, formatted)
+	}
+}
+
+func TestFormatLimitsNestingDepth(t *testing.T) {
+	tests := []struct {
+		input          string
+		maxDepth       int
+		expectedColumn int
+	}{
+		{strings.Repeat("[", 500000), 0, 1001},
+		{`[[{"a": []}]]`, 3, 9},
+		{`[[{"a": 1}]]`, 3, 0},
+	}
+
+	for i, test := range tests {
+		_, err := Format(test.input, FormatterOptions{Parser: parser.ParserOptions{MaxDepth: test.maxDepth}})
+		if test.expectedColumn == 0 {
+			if err != nil {
+				t.Fatalf("tests[%d] - Format returned an error. Error: %q", i, err)
+			}
+			continue
+		}
+
+		if len(err) != 1 || err[0].Code != parser.CodeMaxDepth || err[0].Column != test.expectedColumn {
+			t.Fatalf("tests[%d] - expected %s at column %d, but got %q", i, parser.CodeMaxDepth, test.expectedColumn, err)
+		}
+	}
+
+	if _, err := Minify(strings.Repeat("{\"a\":", 500000)); len(err) != 1 || err[0].Code != parser.CodeMaxDepth {
+		t.Fatalf("Minify did not limit the nesting depth, got %q", err)
+	}
+}
+
+func TestFormatResultKeepsOrderOfOrderedObjects(t *testing.T) {
+	result, errors := jsonparser.Parse(`{"b": [1, 2.5], "a": {"d": 1, "c": 2}}`, parser.ParserOptions{OrderedObjects: true})
+	if errors != nil {
+		t.Fatalf("Parse returned an error. Error: %q", errors)
+	}
+
+	formatted, err := FormatResult(result, FormatterOptions{Indent: "  "})
+	if err != nil {
+		t.Fatalf("FormatResult returned an error. Error: %q", err)
+	}
+
+	expected := "{\n  \"b\": [\n    1,\n    2.5\n  ],\n  \"a\": {\n    \"d\": 1,\n    \"c\": 2\n  }\n}\n"
+	if formatted != expected {
+		t.Fatalf("Unexpected output.\nExpected=%q\nbut got= %q", expected, formatted)
+	}
+}
+
+func TestFormatAppliesParserOptions(t *testing.T) {
+	input := `{"a": [1, 2,],}`
+
+	minified, err := Minify(input)
+	if err != nil || minified != `{"a":[1,2]}` {
+		t.Fatalf("Unexpected lenient output %q, %q", minified, err)
+	}
+
+	minified, err = Minify(`{"a": null}`, parser.ParserOptions{TrackPositions: true})
+	if err != nil || minified != `{"a":null}` {
+		t.Fatalf("Unexpected output with TrackPositions %q, %q", minified, err)
+	}
+
+	tests := []struct {
+		options      parser.ParserOptions
+		input        string
+		expectedCode parser.ErrorCode
+	}{
+		{parser.ParserOptions{Strict: true}, input, parser.CodeTrailingComma},
+		{parser.ParserOptions{Limits: parser.ParserLimits{MaxArrayLength: 1}}, input, parser.CodeArrayTooLong},
+		{parser.ParserOptions{Limits: parser.ParserLimits{MaxStringLength: 2}}, `["abc"]`, parser.CodeStringTooLong},
+		{parser.ParserOptions{DuplicateKeys: parser.DuplicateKeysError}, `{"a": 1, "a": 2}`, parser.CodeDuplicateKey},
+	}
+
+	for i, test := range tests {
+		_, formatErr := Format(test.input, FormatterOptions{Parser: test.options})
+		_, minifyErr := Minify(test.input, test.options)
+		_, parseErr := jsonparser.Parse(test.input, test.options)
+
+		for _, err := range []parser.ParserErrors{formatErr, minifyErr} {
+			if len(err) != 1 || err[0].Code != test.expectedCode || err[0].Message != parseErr[0].Message || err[0].Offset != parseErr[0].Offset {
+				t.Fatalf("tests[%d] - expected the error of the parser %q, but got %q", i, parseErr, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,550 @@
+package jsonparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"sw/json-parser/lexer"
+	"sw/json-parser/parser"
+	"sw/json-parser/token"
+)
+
+var (
+	orderedObjectType        = reflect.TypeOf(parser.OrderedObject{})
+	orderedObjectPointerType = reflect.TypeOf(&parser.OrderedObject{})
+	numberType               = reflect.TypeOf(parser.Number(""))
+	bigIntType               = reflect.TypeOf(big.Int{})
+	bigRatType               = reflect.TypeOf(big.Rat{})
+	bigFloatType             = reflect.TypeOf(big.Float{})
+)
+
+// Encoder writes Go values as JSON to an io.Writer. Besides the values
+// produced by the parser (map[string]any, []any, int, float64, string, bool,
+// nil and the number types of the UseNumber and BigNumbers options) it
+// supports structs, slices, arrays, maps with string or integer keys,
+// pointers and basic types. Map keys are written in sorted order.
+type Encoder struct {
+	output io.Writer
+	writer *bufio.Writer
+	indent string
+
+	// level counts the pointers, maps and slices being encoded, once it gets
+	// deep enough the ones in seen are checked for cycles
+	level int
+	seen  map[cycleKey]struct{}
+}
+
+// startDetectingCyclesAfter is the nesting level of pointers, maps and slices
+// after which the encoder looks for cycles, like encoding/json does. Checking
+// only deeply nested values keeps the common case fast.
+const startDetectingCyclesAfter = 1000
+
+// cycleKey identifies a pointer, a map or a slice. The length tells apart
+// slices sharing the same start, like s and s[:1].
+type cycleKey struct {
+	pointer uintptr
+	length  int
+}
+
+func NewEncoder(output io.Writer) *Encoder {
+	return &Encoder{output: output, writer: bufio.NewWriter(output)}
+}
+
+// SetIndent makes the encoder put every array value and object member on its
+// own line, indented with the given string per nesting level.
+func (encoder *Encoder) SetIndent(indent string) {
+	encoder.indent = indent
+}
+
+// Encode writes the JSON of v followed by a newline. Nothing more than what
+// was already flushed to the writer is written when v cannot be encoded.
+func (encoder *Encoder) Encode(v any) error {
+	if err := encoder.encodeValue(reflect.ValueOf(v), 0); err != nil {
+		encoder.writer.Reset(encoder.output)
+
+		return err
+	}
+	encoder.writer.WriteByte('\n')
+
+	return encoder.writer.Flush()
+}
+
+// Marshal returns the JSON of v. Parsing the output with Parse gives back
+// the same values, floats with an integral value keep a fraction (1.0) so
+// that they are not turned into ints.
+func Marshal(v any) ([]byte, error) {
+	return marshal(v, "")
+}
+
+// MarshalIndent works like Marshal, but indents the output like SetIndent.
+func MarshalIndent(v any, indent string) ([]byte, error) {
+	return marshal(v, indent)
+}
+
+func marshal(v any, indent string) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	encoder := NewEncoder(&buffer)
+	encoder.SetIndent(indent)
+	if err := encoder.encodeValue(reflect.ValueOf(v), 0); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (encoder *Encoder) encodeValue(value reflect.Value, depth int) error {
+	if value.IsValid() == false {
+		encoder.writer.WriteString("null")
+
+		return nil
+	}
+
+	switch value.Type() {
+	case orderedObjectType:
+		object := value.Interface().(parser.OrderedObject)
+
+		return encoder.encodeOrderedObject(&object, depth)
+	case orderedObjectPointerType:
+		if value.IsNil() {
+			encoder.writer.WriteString("null")
+
+			return nil
+		}
+
+		return encoder.encodeOrderedObject(value.Interface().(*parser.OrderedObject), depth)
+	case numberType:
+		return encoder.encodeNumber(parser.Number(value.String()))
+	case bigIntType, bigRatType, bigFloatType:
+		// the values are copied, since they are not always addressable
+		number := reflect.New(value.Type())
+		number.Elem().Set(value)
+
+		return encoder.encodeBigNumber(number.Interface())
+	case reflect.PointerTo(bigIntType), reflect.PointerTo(bigRatType), reflect.PointerTo(bigFloatType):
+		if value.IsNil() {
+			encoder.writer.WriteString("null")
+
+			return nil
+		}
+
+		return encoder.encodeBigNumber(value.Interface())
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			encoder.writer.WriteString("null")
+
+			return nil
+		}
+
+		if value.Kind() == reflect.Pointer {
+			if err := encoder.enter(value); err != nil {
+				return err
+			}
+			defer encoder.leave(value)
+		}
+
+		return encoder.encodeValue(value.Elem(), depth)
+	case reflect.Bool:
+		encoder.writer.WriteString(strconv.FormatBool(value.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		encoder.writer.WriteString(strconv.FormatInt(value.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		encoder.writer.WriteString(strconv.FormatUint(value.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		formatted, err := formatFloat(value.Float(), value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		encoder.writer.WriteString(formatted)
+	case reflect.String:
+		writeString(encoder.writer, value.String())
+	case reflect.Slice:
+		if value.IsNil() {
+			encoder.writer.WriteString("null")
+
+			return nil
+		}
+
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			// like encoding/json, byte slices are written as base64 strings
+			encoder.writer.WriteByte('"')
+			encoder.writer.WriteString(base64.StdEncoding.EncodeToString(value.Bytes()))
+			encoder.writer.WriteByte('"')
+
+			return nil
+		}
+
+		if err := encoder.enter(value); err != nil {
+			return err
+		}
+		defer encoder.leave(value)
+
+		return encoder.encodeArray(value, depth)
+	case reflect.Array:
+		return encoder.encodeArray(value, depth)
+	case reflect.Map:
+		if value.IsNil() {
+			encoder.writer.WriteString("null")
+
+			return nil
+		}
+
+		if err := encoder.enter(value); err != nil {
+			return err
+		}
+		defer encoder.leave(value)
+
+		return encoder.encodeMap(value, depth)
+	case reflect.Struct:
+		return encoder.encodeStruct(value, depth)
+	default:
+		return fmt.Errorf("values of type %s cannot be encoded as JSON", value.Type())
+	}
+
+	return nil
+}
+
+// enter records a pointer, a map or a slice as being encoded and reports an
+// error when it is already being encoded further up, which would make the
+// encoder recurse forever.
+func (encoder *Encoder) enter(value reflect.Value) error {
+	encoder.level++
+	if encoder.level <= startDetectingCyclesAfter {
+		return nil
+	}
+
+	key := cycleKey{pointer: value.Pointer()}
+	if value.Kind() == reflect.Slice {
+		key.length = value.Len()
+	}
+
+	if _, ok := encoder.seen[key]; ok {
+		encoder.level--
+
+		return fmt.Errorf("values of type %s cannot be encoded as JSON, they contain a cycle", value.Type())
+	}
+
+	if encoder.seen == nil {
+		encoder.seen = make(map[cycleKey]struct{})
+	}
+	encoder.seen[key] = struct{}{}
+
+	return nil
+}
+
+func (encoder *Encoder) leave(value reflect.Value) {
+	if encoder.level > startDetectingCyclesAfter {
+		key := cycleKey{pointer: value.Pointer()}
+		if value.Kind() == reflect.Slice {
+			key.length = value.Len()
+		}
+		delete(encoder.seen, key)
+	}
+	encoder.level--
+}
+
+func (encoder *Encoder) encodeArray(value reflect.Value, depth int) error {
+	encoder.writer.WriteByte('[')
+
+	for idx := range value.Len() {
+		if idx > 0 {
+			encoder.writer.WriteByte(',')
+		}
+		encoder.writeNewline(depth + 1)
+
+		if err := encoder.encodeValue(value.Index(idx), depth+1); err != nil {
+			return err
+		}
+	}
+
+	if value.Len() > 0 {
+		encoder.writeNewline(depth)
+	}
+	encoder.writer.WriteByte(']')
+
+	return nil
+}
+
+func (encoder *Encoder) encodeMap(value reflect.Value, depth int) error {
+	type member struct {
+		key   string
+		value reflect.Value
+	}
+
+	members := make([]member, 0, value.Len())
+	iterator := value.MapRange()
+	for iterator.Next() {
+		key := iterator.Key()
+
+		switch key.Kind() {
+		case reflect.String:
+			members = append(members, member{key.String(), iterator.Value()})
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			members = append(members, member{strconv.FormatInt(key.Int(), 10), iterator.Value()})
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			members = append(members, member{strconv.FormatUint(key.Uint(), 10), iterator.Value()})
+		default:
+			return fmt.Errorf("map keys of type %s cannot be encoded as JSON", key.Type())
+		}
+	}
+
+	slices.SortFunc(members, func(a member, b member) int { return strings.Compare(a.key, b.key) })
+
+	encoder.writer.WriteByte('{')
+	for idx, member := range members {
+		if idx > 0 {
+			encoder.writer.WriteByte(',')
+		}
+
+		encoder.writeKey(member.key, depth+1)
+		if err := encoder.encodeValue(member.value, depth+1); err != nil {
+			return err
+		}
+	}
+
+	if len(members) > 0 {
+		encoder.writeNewline(depth)
+	}
+	encoder.writer.WriteByte('}')
+
+	return nil
+}
+
+// encodeNumber writes the literal of the number as it is, like encoding/json
+// an empty Number is written as 0.
+func (encoder *Encoder) encodeNumber(number parser.Number) error {
+	if number == "" {
+		number = "0"
+	}
+
+	if isValidNumber(string(number)) == false {
+		return fmt.Errorf("%q is not a valid JSON number", string(number))
+	}
+	encoder.writer.WriteString(string(number))
+
+	return nil
+}
+
+// encodeBigNumber writes a *big.Int, *big.Rat or *big.Float without rounding
+// it.
+func (encoder *Encoder) encodeBigNumber(number any) error {
+	literal, err := parser.FormatNumber(number)
+	if err != nil {
+		return err
+	}
+	encoder.writer.WriteString(literal)
+
+	return nil
+}
+
+// isValidNumber reports whether the literal is a single number following the
+// JSON grammar, without any whitespace around it.
+func isValidNumber(literal string) bool {
+	l := lexer.New(literal)
+	numberToken := l.ReadToken()
+	if numberToken.Type != token.NUMBER || len(l.TakeErrors()) > 0 {
+		return false
+	}
+
+	return numberToken.Offset == 0 && numberToken.EndOffset == len(literal)
+}
+
+// encodeOrderedObject writes the members in their order, unlike maps, whose
+// keys get sorted.
+func (encoder *Encoder) encodeOrderedObject(object *parser.OrderedObject, depth int) error {
+	encoder.writer.WriteByte('{')
+
+	for idx, key := range object.Keys() {
+		if idx > 0 {
+			encoder.writer.WriteByte(',')
+		}
+
+		value, _ := object.Get(key)
+		encoder.writeKey(key, depth+1)
+		if err := encoder.encodeValue(reflect.ValueOf(value), depth+1); err != nil {
+			return err
+		}
+	}
+
+	if object.Len() > 0 {
+		encoder.writeNewline(depth)
+	}
+	encoder.writer.WriteByte('}')
+
+	return nil
+}
+
+func (encoder *Encoder) encodeStruct(value reflect.Value, depth int) error {
+	encoder.writer.WriteByte('{')
+
+	written := 0
+	for _, field := range structFields(value.Type()) {
+		fieldValue, ok := embeddedFieldByIndex(value, field.index)
+		if ok == false || (field.omitEmpty && isEmptyValue(fieldValue)) {
+			continue
+		}
+
+		if written > 0 {
+			encoder.writer.WriteByte(',')
+		}
+		written++
+
+		encoder.writeKey(field.name, depth+1)
+		if err := encoder.encodeValue(fieldValue, depth+1); err != nil {
+			return err
+		}
+	}
+
+	if written > 0 {
+		encoder.writeNewline(depth)
+	}
+	encoder.writer.WriteByte('}')
+
+	return nil
+}
+
+func (encoder *Encoder) writeKey(key string, depth int) {
+	encoder.writeNewline(depth)
+	writeString(encoder.writer, key)
+	encoder.writer.WriteByte(':')
+
+	if encoder.indent != "" {
+		encoder.writer.WriteByte(' ')
+	}
+}
+
+func (encoder *Encoder) writeNewline(depth int) {
+	if encoder.indent == "" {
+		return
+	}
+
+	encoder.writer.WriteByte('\n')
+	for range depth {
+		encoder.writer.WriteString(encoder.indent)
+	}
+}
+
+// embeddedFieldByIndex works like reflect.Value.FieldByIndex, but reports
+// a nil pointer to an embedded struct instead of panicking.
+func embeddedFieldByIndex(structValue reflect.Value, index []int) (reflect.Value, bool) {
+	for idx, fieldIdx := range index {
+		if idx > 0 && structValue.Kind() == reflect.Pointer {
+			if structValue.IsNil() {
+				return reflect.Value{}, false
+			}
+			structValue = structValue.Elem()
+		}
+		structValue = structValue.Field(fieldIdx)
+	}
+
+	return structValue, true
+}
+
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return value.IsZero()
+	}
+
+	return false
+}
+
+// formatFloat formats floats like encoding/json, but always keeps a fraction
+// or an exponent, so that the number is parsed back as a float.
+func formatFloat(number float64, bits int) (string, error) {
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return "", fmt.Errorf("unsupported float value %v", number)
+	}
+
+	format := byte('f')
+	if abs := math.Abs(number); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+
+	formatted := strconv.FormatFloat(number, format, -1, bits)
+	if format == 'e' {
+		return formatted, nil
+	}
+
+	if strings.ContainsRune(formatted, '.') == false {
+		formatted += ".0"
+	}
+
+	return formatted, nil
+}
+
+// QuoteString returns the string quoted and escaped as a JSON string.
+func QuoteString(str string) string {
+	var builder strings.Builder
+
+	writer := bufio.NewWriter(&builder)
+	writeString(writer, str)
+	writer.Flush()
+
+	return builder.String()
+}
+
+// writeString writes a quoted JSON string. Invalid UTF-8 is replaced with
+// U+FFFD.
+func writeString(writer *bufio.Writer, str string) {
+	writer.WriteByte('"')
+
+	for idx := 0; idx < len(str); {
+		char := str[idx]
+		if char < utf8.RuneSelf {
+			switch char {
+			case '"', '\\':
+				writer.WriteByte('\\')
+				writer.WriteByte(char)
+			case '\b':
+				writer.WriteString(`\b`)
+			case '\f':
+				writer.WriteString(`\f`)
+			case '\n':
+				writer.WriteString(`\n`)
+			case '\r':
+				writer.WriteString(`\r`)
+			case '\t':
+				writer.WriteString(`\t`)
+			default:
+				if char < 0x20 {
+					fmt.Fprintf(writer, `\u%04x`, char)
+				} else {
+					writer.WriteByte(char)
+				}
+			}
+			idx++
+
+			continue
+		}
+
+		codePoint, size := utf8.DecodeRuneInString(str[idx:])
+		if codePoint == utf8.RuneError && size == 1 {
+			writer.WriteString(`\ufffd`)
+		} else {
+			writer.WriteString(str[idx : idx+size])
+		}
+		idx += size
+	}
+
+	writer.WriteByte('"')
+}
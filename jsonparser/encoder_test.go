@@ -0,0 +1,361 @@
+package jsonparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sw/json-parser/parser"
+)
+
+func TestMarshalParsedValues(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{nil, `null`},
+		{true, `true`},
+		{88, `88`},
+		{-99.78, `-99.78`},
+		{1.0, `1.0`},
+		{1e21, `1e+21`},
+		{0.0000001, `1e-07`},
+		{"say \"hi\"\n\t\\ \x01 zażółć 😀", `"say \"hi\"\n\t\\ \u0001 zażółć 😀"`},
+		{"invalid \xff utf-8", `"invalid \ufffd utf-8"`},
+		{[]any{}, `[]`},
+		{map[string]any{}, `{}`},
+		{[]any{1, "two", nil, []any{false}}, `[1,"two",null,[false]]`},
+		{map[string]any{"b": 1, "a": map[string]any{"z": nil, "y": 2.5}}, `{"a":{"y":2.5,"z":null},"b":1}`},
+		{map[int]string{10: "ten", 2: "two"}, `{"10":"ten","2":"two"}`},
+	}
+
+	for i, test := range tests {
+		encoded, err := Marshal(test.value)
+		if err != nil {
+			t.Fatalf("tests[%d] - Marshal returned an error. Error: %q", i, err)
+		}
+
+		if string(encoded) != test.expected {
+			t.Fatalf("tests[%d] - output is wrong. Expected=%s, but got=%s", i, test.expected, encoded)
+		}
+	}
+}
+
+func TestMarshalStructs(t *testing.T) {
+	zip := "0150"
+	value := customer{
+		audit:    audit{CreatedBy: "admin"},
+		Name:     "Joe",
+		Age:      88,
+		Address:  &address{City: "Oslo", Zip: &zip},
+		Orders:   []order{{Name: "book", Price: 11.99, Count: 2}, {Name: "ball", Count: 1}},
+		Tags:     [2]string{"a", "b"},
+		Ignored:  "ignored",
+		internal: "internal",
+	}
+
+	encoded, err := Marshal(value)
+	if err != nil {
+		t.Fatalf("Marshal returned an error. Error: %q", err)
+	}
+
+	expected := `{"created_by":"admin","name":"Joe","age":88,"active":false,"address":{"city":"Oslo","Zip":"0150"},"orders":[{"name":"book","price":11.99,"count":2},{"name":"ball","count":1}],"tags":["a","b"],"scores":null,"by_id":null,"extra":null,"labels":null}`
+	if string(encoded) != expected {
+		t.Fatalf("Output is wrong.\nExpected=%s\nbut got= %s", expected, encoded)
+	}
+}
+
+func TestMarshalRoundTripsThroughParse(t *testing.T) {
+	input := `{"name": "Joe \"J\" Doe", "age": -88, "salary": 99.78, "round": 100.0, "tiny": 1.5e-9, "tags": ["a", "é", "\n"], "address": {"city": "Oslo", "zip": null}, "active": true, "matrix": [[1, 2], [3.5]]}`
+
+	result, errors := Parse(input)
+	if errors != nil {
+		t.Fatalf("Parse returned an error. Error: %q", errors)
+	}
+
+	encoded, err := Marshal(result.Value)
+	if err != nil {
+		t.Fatalf("Marshal returned an error. Error: %q", err)
+	}
+
+	roundTripped, errors := Parse(string(encoded))
+	if errors != nil {
+		t.Fatalf("Parse of %s returned an error. Error: %q", encoded, errors)
+	}
+
+	if reflect.DeepEqual(result.Value, roundTripped.Value) == false {
+		t.Fatalf("Round trip changed the value.\nExpected %v\nbut got  %v", result.Value, roundTripped.Value)
+	}
+}
+
+func TestEncoderWritesIndentedValues(t *testing.T) {
+	var buffer bytes.Buffer
+
+	encoder := NewEncoder(&buffer)
+	encoder.SetIndent("  ")
+
+	if err := encoder.Encode(map[string]any{"name": "Joe", "orders": []any{1, map[string]any{}}}); err != nil {
+		t.Fatalf("Encode returned an error. Error: %q", err)
+	}
+
+	if err := encoder.Encode([]any{}); err != nil {
+		t.Fatalf("Encode returned an error. Error: %q", err)
+	}
+
+	expected := "{\n  \"name\": \"Joe\",\n  \"orders\": [\n    1,\n    {}\n  ]\n}\n[]\n"
+	if buffer.String() != expected {
+		t.Fatalf("Output is wrong.\nExpected=%q\nbut got= %q", expected, buffer.String())
+	}
+}
+
+func TestEncoderRejectsUnsupportedValues(t *testing.T) {
+	var buffer bytes.Buffer
+	encoder := NewEncoder(&buffer)
+
+	tests := []any{math.NaN(), math.Inf(1), []any{1, make(chan int)}, map[bool]int{true: 1}, func() {}}
+	for i, value := range tests {
+		if err := encoder.Encode(value); err == nil {
+			t.Fatalf("tests[%d] - Encode did not return an error", i)
+		}
+	}
+
+	if buffer.Len() != 0 {
+		t.Fatalf("Encoder wrote a partial value %q", buffer.String())
+	}
+}
+
+func TestMarshalKeepsOrderOfOrderedObjects(t *testing.T) {
+	input := `{"zeta":1,"alpha":{"y":true,"x":null},"mid":[{"b":1,"a":2.5}]}`
+
+	result, errors := Parse(input, parser.ParserOptions{OrderedObjects: true})
+	if errors != nil {
+		t.Fatalf("Parse returned an error. Error: %q", errors)
+	}
+
+	encoded, err := Marshal(result.Value)
+	if err != nil {
+		t.Fatalf("Marshal returned an error. Error: %q", err)
+	}
+
+	if string(encoded) != input {
+		t.Fatalf("Output is wrong. Expected=%s, but got=%s", input, encoded)
+	}
+
+	indented, err := MarshalIndent(map[string]any{"object": result.Value.(*parser.OrderedObject).Map()["alpha"]}, "  ")
+	if err != nil {
+		t.Fatalf("MarshalIndent returned an error. Error: %q", err)
+	}
+
+	expected := "{\n  \"object\": {\n    \"y\": true,\n    \"x\": null\n  }\n}"
+	if string(indented) != expected {
+		t.Fatalf("Output is wrong. Expected=%q, but got=%q", expected, indented)
+	}
+}
+
+func TestMarshalWritesNumbersVerbatim(t *testing.T) {
+	input := `{"amount":1.10,"id":123456789012345678901234567890,"rate":2.50E-3,"small":-0}`
+
+	result, errors := Parse(input, parser.ParserOptions{UseNumber: true})
+	if errors != nil {
+		t.Fatalf("Parse returned an error. Error: %q", errors)
+	}
+
+	encoded, err := Marshal(result.Value)
+	if err != nil {
+		t.Fatalf("Marshal returned an error. Error: %q", err)
+	}
+
+	if string(encoded) != input {
+		t.Fatalf("Output is wrong. Expected=%s, but got=%s", input, encoded)
+	}
+
+	tests := []struct {
+		number   parser.Number
+		expected string
+	}{
+		{"", "0"},
+		{"12", "12"},
+		{"1.", "error"},
+		{"0x10", "error"},
+		{"1 2", "error"},
+		{"NaN", "error"},
+		{" 1", "error"},
+		{"1\n", "error"},
+		{"\t-1.5e3 ", "error"},
+	}
+
+	for i, test := range tests {
+		encoded, err := Marshal(test.number)
+		if err != nil {
+			encoded = []byte("error")
+		}
+
+		if string(encoded) != test.expected {
+			t.Fatalf("tests[%d] - output is wrong. Expected=%s, but got=%s", i, test.expected, encoded)
+		}
+	}
+}
+
+func TestMarshalBigNumbers(t *testing.T) {
+	input := `{"amount":19.99,"id":123456789012345678901234567890,"rate":-0.000125}`
+
+	result, errors := Parse(input, parser.ParserOptions{BigNumbers: parser.BigNumbersRat})
+	if errors != nil {
+		t.Fatalf("Parse returned an error. Error: %q", errors)
+	}
+
+	encoded, err := Marshal(result.Value)
+	if err != nil {
+		t.Fatalf("Marshal returned an error. Error: %q", err)
+	}
+
+	if string(encoded) != input {
+		t.Fatalf("Output is wrong. Expected=%s, but got=%s", input, encoded)
+	}
+
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{big.NewInt(-7), "-7"},
+		{*big.NewRat(5, 4), "1.25"},
+		{big.NewFloat(1e100), "1e+100"},
+		{big.NewRat(2, 3), "error"},
+		{(*big.Int)(nil), "null"},
+	}
+
+	for i, test := range tests {
+		encoded, err := Marshal(test.value)
+		if err != nil {
+			encoded = []byte("error")
+		}
+
+		if string(encoded) != test.expected {
+			t.Fatalf("tests[%d] - output is wrong. Expected=%s, but got=%s", i, test.expected, encoded)
+		}
+	}
+}
+
+func TestMarshalByteSlices(t *testing.T) {
+	tests := []any{
+		[]byte("hi"),
+		[]byte{},
+		[]byte(nil),
+		map[string][]byte{"raw": {0, 255, 10}},
+		[2]byte{1, 2},
+	}
+
+	for i, value := range tests {
+		encoded, err := Marshal(value)
+		if err != nil {
+			t.Fatalf("tests[%d] - Marshal returned an error. Error: %q", i, err)
+		}
+
+		expected, _ := json.Marshal(value)
+		if string(encoded) != string(expected) {
+			t.Fatalf("tests[%d] - output is wrong. Expected=%s, but got=%s", i, expected, encoded)
+		}
+	}
+
+	var decoded []byte
+	if err := Unmarshal(`"aGk="`, &decoded); err != nil || string(decoded) != "hi" {
+		t.Fatalf("Unmarshal returned %q, %v", decoded, err)
+	}
+}
+
+func TestMarshalRejectsCycles(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+
+	selfMap := map[string]any{}
+	selfMap["self"] = selfMap
+
+	selfSlice := []any{nil}
+	selfSlice[0] = selfSlice
+
+	ring := &node{Name: "a"}
+	ring.Next = &node{Name: "b", Next: ring}
+
+	tests := []any{selfMap, selfSlice, ring}
+	for i, value := range tests {
+		if _, err := Marshal(value); err == nil || strings.Contains(err.Error(), "cycle") == false {
+			t.Fatalf("tests[%d] - expected an error about a cycle, but got=%v", i, err)
+		}
+	}
+
+	// deep values without a cycle are still encoded
+	var deep any = 1
+	for range 2 * startDetectingCyclesAfter {
+		deep = []any{deep}
+	}
+
+	encoded, err := Marshal(deep)
+	if err != nil {
+		t.Fatalf("Marshal returned an error. Error: %q", err)
+	}
+
+	expected := strings.Repeat("[", 2*startDetectingCyclesAfter) + "1" + strings.Repeat("]", 2*startDetectingCyclesAfter)
+	if string(encoded) != expected {
+		t.Fatalf("Output of the deep value is wrong, got=%.50s...", encoded)
+	}
+}
+
+type selfEmbedding struct {
+	*selfEmbedding
+	X int
+}
+
+type untaggedName struct {
+	Name string
+	ID   int
+}
+
+type taggedName struct {
+	X  string `json:"Name"`
+	ID int
+}
+
+type conflictingFields struct {
+	untaggedName
+	taggedName
+}
+
+func TestMarshalResolvesFieldsLikeEncodingJson(t *testing.T) {
+	tests := []any{
+		selfEmbedding{X: 1},
+		conflictingFields{untaggedName{Name: "untagged", ID: 1}, taggedName{X: "tagged", ID: 2}},
+		struct {
+			taggedName
+			Name string
+		}{taggedName{X: "deep"}, "shallow"},
+	}
+
+	for i, value := range tests {
+		expected, _ := json.Marshal(value)
+
+		encoded, err := Marshal(value)
+		if err != nil {
+			t.Fatalf("tests[%d] - Marshal returned an error. Error: %q", i, err)
+		}
+
+		if string(encoded) != string(expected) {
+			t.Fatalf("tests[%d] - output is wrong. Expected=%s, but got=%s", i, expected, encoded)
+		}
+	}
+
+	var embedding selfEmbedding
+	if err := Unmarshal(`{"X": 1}`, &embedding); err != nil || embedding.X != 1 {
+		t.Fatalf("Unexpected value %+v, %v", embedding, err)
+	}
+
+	var conflicting conflictingFields
+	if err := Unmarshal(`{"Name": "tagged", "ID": 3}`, &conflicting); err != nil || conflicting.X != "tagged" || conflicting.untaggedName.Name != "" || conflicting.untaggedName.ID != 0 || conflicting.taggedName.ID != 0 {
+		t.Fatalf("Unexpected value %+v, %v", conflicting, err)
+	}
+}
@@ -0,0 +1,171 @@
+package jsonparser
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// field describes a struct field the way it is seen by Marshal and Unmarshal.
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	// tagged is set when the name comes from the json tag
+	tagged bool
+}
+
+// parseTag splits a `json:"name,omitempty"` tag into the name and its options.
+func parseTag(tag string) (string, []string) {
+	name, options, _ := strings.Cut(tag, ",")
+	if options == "" {
+		return name, nil
+	}
+
+	return name, strings.Split(options, ",")
+}
+
+// fieldCache holds the fields of every struct type seen so far, see
+// structFields.
+var fieldCache sync.Map
+
+// structFields returns the fields of a struct type following the rules of
+// encoding/json: unexported fields and fields tagged with "-" are skipped,
+// fields of embedded structs without a tag are promoted, and a shallower
+// field hides deeper fields with the same name. Of several fields with the
+// same name at the same depth a tagged one wins, when that does not decide
+// it, all of them are dropped.
+func structFields(structType reflect.Type) []field {
+	if fields, ok := fieldCache.Load(structType); ok {
+		return fields.([]field)
+	}
+
+	fields, _ := fieldCache.LoadOrStore(structType, collectFields(structType))
+
+	return fields.([]field)
+}
+
+// collectFields walks the struct and its embedded structs breadth first, so
+// that shallower fields are found before deeper ones. A struct type is only
+// walked at the shallowest depth it is embedded at, which also ends the walk
+// for types embedding themselves.
+func collectFields(structType reflect.Type) []field {
+	type embedded struct {
+		structType reflect.Type
+		index      []int
+	}
+
+	var candidates []field
+	visited := make(map[reflect.Type]bool)
+
+	next := []embedded{{structType: structType}}
+	for len(next) > 0 {
+		current := next
+		next = nil
+
+		for _, embeddedStruct := range current {
+			if visited[embeddedStruct.structType] {
+				continue
+			}
+
+			for idx := range embeddedStruct.structType.NumField() {
+				structField := embeddedStruct.structType.Field(idx)
+				tag := structField.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+
+				name, options := parseTag(tag)
+				fieldIndex := append(append([]int{}, embeddedStruct.index...), idx)
+
+				fieldType := structField.Type
+				if fieldType.Kind() == reflect.Pointer {
+					fieldType = fieldType.Elem()
+				}
+
+				if structField.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+					next = append(next, embedded{structType: fieldType, index: fieldIndex})
+
+					continue
+				}
+
+				if structField.IsExported() == false {
+					continue
+				}
+
+				tagged := name != ""
+				if tagged == false {
+					name = structField.Name
+				}
+
+				candidates = append(candidates, field{name: name, index: fieldIndex, omitEmpty: slices.Contains(options, "omitempty"), tagged: tagged})
+			}
+		}
+
+		// types embedded several times at the same depth are walked every
+		// time, so that their fields collide with each other
+		for _, embeddedStruct := range current {
+			visited[embeddedStruct.structType] = true
+		}
+	}
+
+	// order the fields of every name by depth, tagged fields first
+	slices.SortStableFunc(candidates, func(a field, b field) int {
+		if a.name != b.name {
+			return strings.Compare(a.name, b.name)
+		}
+
+		if len(a.index) != len(b.index) {
+			return len(a.index) - len(b.index)
+		}
+
+		if a.tagged != b.tagged {
+			if a.tagged {
+				return -1
+			}
+
+			return 1
+		}
+
+		return 0
+	})
+
+	var fields []field
+	for start := 0; start < len(candidates); {
+		end := start + 1
+		for end < len(candidates) && candidates[end].name == candidates[start].name {
+			end++
+		}
+
+		first := candidates[start]
+		ambiguous := end-start > 1 && len(candidates[start+1].index) == len(first.index) && candidates[start+1].tagged == first.tagged
+		if ambiguous == false {
+			fields = append(fields, first)
+		}
+		start = end
+	}
+
+	// keep the order in which the fields were declared
+	slices.SortFunc(fields, func(a field, b field) int { return slices.Compare(a.index, b.index) })
+
+	return fields
+}
+
+// fieldByName looks up a field the way encoding/json does, preferring an
+// exact match over a case-insensitive one.
+func fieldByName(fields []field, name string) (field, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+
+	return field{}, false
+}
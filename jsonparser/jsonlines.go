@@ -0,0 +1,106 @@
+package jsonparser
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+
+	"sw/json-parser/lexer"
+	"sw/json-parser/parser"
+)
+
+// JSONLinesReader reads newline-delimited JSON (JSON Lines, NDJSON), where
+// every line holds one value. Lines containing only whitespace are skipped.
+// The positions of the errors point into the whole input, so the line of an
+// error is the line of the record.
+type JSONLinesReader struct {
+	reader  *bufio.Reader
+	options parser.ParserOptions
+	// SkipInvalid makes Read skip the lines that are not valid JSON. Their
+	// errors are collected and returned by Errors.
+	SkipInvalid bool
+
+	line   int
+	offset int
+	errors parser.ParserErrors
+}
+
+// NewJSONLinesReader creates a reader for the JSON Lines input. Options are
+// optional, only the first one passed is used. AllowTrailingData is ignored,
+// since every line has to hold exactly one value.
+func NewJSONLinesReader(reader io.Reader, options ...parser.ParserOptions) *JSONLinesReader {
+	linesReader := JSONLinesReader{reader: bufio.NewReader(reader)}
+	if len(options) > 0 {
+		linesReader.options = options[0]
+	}
+	linesReader.options.AllowTrailingData = false
+
+	return &linesReader
+}
+
+// Read parses the next record. It returns io.EOF once all the lines were
+// read. An invalid line is returned as parser.ParserErrors, unless
+// SkipInvalid is set, and the next call continues with the following line.
+func (linesReader *JSONLinesReader) Read() (*parser.ParserResult, error) {
+	for {
+		line, err := linesReader.reader.ReadString('\n')
+		if err != nil && errors.Is(err, io.EOF) == false {
+			return nil, err
+		}
+
+		if line == "" {
+			return nil, io.EOF
+		}
+
+		linesReader.line++
+		offset := linesReader.offset
+		linesReader.offset += len(line)
+
+		// without the line break, the end of a truncated record is reported
+		// on its own line
+		record := strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if strings.Trim(record, " \t") == "" {
+			continue
+		}
+
+		lexer := lexer.NewAt(record, lexer.ParseContext{Line: linesReader.line, Column: 1, Offset: offset})
+		result, parserErrors := parser.New(lexer, linesReader.options).Parse()
+		if parserErrors != nil {
+			if linesReader.SkipInvalid {
+				linesReader.errors = append(linesReader.errors, parserErrors...)
+
+				continue
+			}
+
+			return nil, parserErrors
+		}
+
+		return result, nil
+	}
+}
+
+// Line returns the line of the last record read.
+func (linesReader *JSONLinesReader) Line() int {
+	return linesReader.line
+}
+
+// Errors returns the errors of the lines skipped so far with SkipInvalid.
+func (linesReader *JSONLinesReader) Errors() parser.ParserErrors {
+	return linesReader.errors
+}
+
+// JSONLinesWriter writes values as JSON Lines, one compact value per line.
+type JSONLinesWriter struct {
+	encoder *Encoder
+}
+
+func NewJSONLinesWriter(output io.Writer) *JSONLinesWriter {
+	return &JSONLinesWriter{encoder: NewEncoder(output)}
+}
+
+// Write writes v as a single line. Nothing is written when v cannot be
+// encoded.
+func (linesWriter *JSONLinesWriter) Write(v any) error {
+	return linesWriter.encoder.Encode(v)
+}
@@ -0,0 +1,137 @@
+package jsonparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"sw/json-parser/parser"
+)
+
+func TestJSONLinesReaderReadsRecords(t *testing.T) {
+	input := "{\"id\": 1}\n\n[1, 2]\r\n  \"three\"  \nnull"
+	expected := []string{`map[id:1]`, `[1 2]`, `three`, `<nil>`}
+	expectedLines := []int{1, 3, 4, 5}
+
+	reader := NewJSONLinesReader(strings.NewReader(input))
+	for i := range expected {
+		result, err := reader.Read()
+		if err != nil {
+			t.Fatalf("tests[%d] - Read returned an error. Error: %q", i, err)
+		}
+
+		if fmt.Sprint(result.Value) != expected[i] {
+			t.Fatalf("tests[%d] - record is wrong. Expected=%s, but got=%v", i, expected[i], result.Value)
+		}
+
+		if reader.Line() != expectedLines[i] {
+			t.Fatalf("tests[%d] - line is wrong. Expected=%d, but got=%d", i, expectedLines[i], reader.Line())
+		}
+	}
+
+	if _, err := reader.Read(); err != io.EOF {
+		t.Fatalf("Expected io.EOF after the last record, but got=%v", err)
+	}
+}
+
+func TestJSONLinesReaderReportsInvalidLines(t *testing.T) {
+	input := "{\"id\": 1}\n{\"id\": 2,, }\n{\"id\": 3}\n{\"id\": 4} 5\n"
+
+	tests := []struct {
+		expectedValue  string
+		expectedCode   parser.ErrorCode
+		expectedLine   int
+		expectedColumn int
+		expectedOffset int
+	}{
+		{`map[id:1]`, "", 0, 0, 0},
+		{"", parser.CodeInvalidKey, 2, 10, 19},
+		{`map[id:3]`, "", 0, 0, 0},
+		{"", parser.CodeTrailingData, 4, 11, 43},
+	}
+
+	reader := NewJSONLinesReader(strings.NewReader(input))
+	for i, test := range tests {
+		result, err := reader.Read()
+		if test.expectedCode == "" {
+			if err != nil || fmt.Sprint(result.Value) != test.expectedValue {
+				t.Fatalf("tests[%d] - record is wrong. Expected=%s, but got=%v (error %v)", i, test.expectedValue, result, err)
+			}
+			continue
+		}
+
+		var syntaxError *parser.SyntaxError
+		if errors.As(err, &syntaxError) == false {
+			t.Fatalf("tests[%d] - expected a syntax error, but got=%v", i, err)
+		}
+
+		if syntaxError.Code != test.expectedCode || syntaxError.Line != test.expectedLine || syntaxError.Column != test.expectedColumn || syntaxError.Offset != test.expectedOffset {
+			t.Fatalf("tests[%d] - error is wrong. Expected=%s at %d:%d (offset %d), but got=%s at %d:%d (offset %d)", i, test.expectedCode, test.expectedLine, test.expectedColumn, test.expectedOffset, syntaxError.Code, syntaxError.Line, syntaxError.Column, syntaxError.Offset)
+		}
+	}
+}
+
+func TestJSONLinesReaderSkipsInvalidLines(t *testing.T) {
+	input := "1\n{\n2\n[\"unterminated\n3\n"
+
+	reader := NewJSONLinesReader(strings.NewReader(input))
+	reader.SkipInvalid = true
+
+	values := []any{}
+	for {
+		result, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Read returned an error. Error: %q", err)
+		}
+		values = append(values, result.Value)
+	}
+
+	if fmt.Sprint(values) != "[1 2 3]" {
+		t.Fatalf("Wrong records. Expected=[1 2 3], but got=%v", values)
+	}
+
+	skipped := reader.Errors()
+	if len(skipped) != 2 || skipped[0].Line != 2 || skipped[1].Line != 4 {
+		t.Fatalf("Wrong errors of the skipped lines. Got=%v", skipped)
+	}
+}
+
+func TestJSONLinesWriterRoundTrip(t *testing.T) {
+	values := []any{
+		map[string]any{"message": "line\nbreak", "level": 3},
+		[]any{1.5, nil},
+		"text",
+	}
+
+	var buffer bytes.Buffer
+	writer := NewJSONLinesWriter(&buffer)
+	for i, value := range values {
+		if err := writer.Write(value); err != nil {
+			t.Fatalf("tests[%d] - Write returned an error. Error: %q", i, err)
+		}
+	}
+
+	expected := "{\"level\":3,\"message\":\"line\\nbreak\"}\n[1.5,null]\n\"text\"\n"
+	if buffer.String() != expected {
+		t.Fatalf("Output is wrong. Expected=%q, but got=%q", expected, buffer.String())
+	}
+
+	reader := NewJSONLinesReader(&buffer)
+	for i, value := range values {
+		result, err := reader.Read()
+		if err != nil {
+			t.Fatalf("tests[%d] - Read returned an error. Error: %q", i, err)
+		}
+
+		if fmt.Sprint(result.Value) != fmt.Sprint(value) {
+			t.Fatalf("tests[%d] - record is wrong. Expected=%v, but got=%v", i, value, result.Value)
+		}
+	}
+}
@@ -1,13 +1,24 @@
 package jsonparser
 
 import (
+	"io"
+
 	"sw/json-parser/lexer"
 	"sw/json-parser/parser"
 )
 
-func Parse(input string) (*parser.ParserResult, parser.ParserErrors) {
+func Parse(input string, options ...parser.ParserOptions) (*parser.ParserResult, parser.ParserErrors) {
 	lexer := lexer.New(input)
-	parser := parser.New(lexer)
+	parser := parser.New(lexer, options...)
+
+	return parser.Parse()
+}
+
+// ParseReader works like Parse, but reads the input from the reader while
+// tokenizing instead of requiring it as a whole string.
+func ParseReader(reader io.Reader, options ...parser.ParserOptions) (*parser.ParserResult, parser.ParserErrors) {
+	lexer := lexer.NewReader(reader)
+	parser := parser.New(lexer, options...)
 
 	return parser.Parse()
 }
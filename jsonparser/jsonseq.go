@@ -0,0 +1,162 @@
+package jsonparser
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+
+	"sw/json-parser/lexer"
+	"sw/json-parser/parser"
+)
+
+// RecordSeparator is the byte starting every record of a JSON text sequence.
+const RecordSeparator = 0x1E
+
+const (
+	CodeMissingRecordSeparator parser.ErrorCode = "missing_record_separator"
+	CodeTruncatedValue         parser.ErrorCode = "truncated_value"
+)
+
+// JSONSeqReader reads JSON text sequences (RFC 7464, application/json-seq),
+// where every record starts with the RS byte (0x1E) and usually ends with a
+// line feed. Following the RFC, a record that cannot be parsed, like one cut
+// off in the middle, does not stop the reading of the records after it.
+type JSONSeqReader struct {
+	reader  *bufio.Reader
+	options parser.ParserOptions
+	// SkipInvalid makes Read skip the records that are not valid JSON. Their
+	// errors are collected and returned by Errors.
+	SkipInvalid bool
+
+	// position of the next byte read
+	position lexer.ParseContext
+	started  bool
+	errors   parser.ParserErrors
+}
+
+// NewJSONSeqReader creates a reader for the JSON text sequence. Options are
+// optional, only the first one passed is used. AllowTrailingData is ignored,
+// since every record has to hold exactly one value.
+func NewJSONSeqReader(reader io.Reader, options ...parser.ParserOptions) *JSONSeqReader {
+	seqReader := JSONSeqReader{reader: bufio.NewReader(reader), position: lexer.ParseContext{Line: 1, Column: 1, Offset: 0}}
+	if len(options) > 0 {
+		seqReader.options = options[0]
+	}
+	seqReader.options.AllowTrailingData = false
+
+	return &seqReader
+}
+
+// Read parses the next record. It returns io.EOF once all the records were
+// read. An invalid record is returned as parser.ParserErrors, unless
+// SkipInvalid is set, and the next call continues with the following record.
+//
+// A top-level number, true, false or null that is not followed by whitespace
+// may have been truncated, so it is reported as CodeTruncatedValue.
+func (seqReader *JSONSeqReader) Read() (*parser.ParserResult, error) {
+	for {
+		chunk, err := seqReader.reader.ReadString(RecordSeparator)
+		if err != nil && errors.Is(err, io.EOF) == false {
+			return nil, err
+		}
+
+		if chunk == "" {
+			return nil, io.EOF
+		}
+
+		start := seqReader.position
+		seqReader.position = seqReader.options.ColumnUnit.Advance(seqReader.position, chunk)
+		record := strings.TrimSuffix(chunk, string(rune(RecordSeparator)))
+
+		started := seqReader.started
+		seqReader.started = true
+		if strings.Trim(record, " \t\r\n") == "" {
+			continue
+		}
+
+		var result *parser.ParserResult
+		var parserErrors parser.ParserErrors
+		if started == false {
+			parserErrors = seqReader.recordError(CodeMissingRecordSeparator, "Expected the record separator (0x1E) at the start of the sequence.", record, start)
+		} else {
+			result, parserErrors = seqReader.parseRecord(record, start)
+		}
+
+		if parserErrors != nil {
+			if seqReader.SkipInvalid {
+				seqReader.errors = append(seqReader.errors, parserErrors...)
+
+				continue
+			}
+
+			return nil, parserErrors
+		}
+
+		return result, nil
+	}
+}
+
+// Errors returns the errors of the records skipped so far with SkipInvalid.
+func (seqReader *JSONSeqReader) Errors() parser.ParserErrors {
+	return seqReader.errors
+}
+
+func (seqReader *JSONSeqReader) parseRecord(record string, start lexer.ParseContext) (*parser.ParserResult, parser.ParserErrors) {
+	result, parserErrors := parser.New(lexer.NewAt(record, start), seqReader.options).Parse()
+	if parserErrors != nil {
+		return nil, parserErrors
+	}
+
+	switch result.Kind() {
+	case parser.KindNumber, parser.KindBool, parser.KindNull:
+		if strings.ContainsAny(record[len(record)-1:], " \t\r\n") == false {
+			return nil, seqReader.recordError(CodeTruncatedValue, "Top-level value is not followed by whitespace, so it may have been truncated.", record, start)
+		}
+	}
+
+	return result, nil
+}
+
+// recordError reports an error spanning the first line of the record,
+// without the surrounding whitespace.
+func (seqReader *JSONSeqReader) recordError(code parser.ErrorCode, message string, record string, start lexer.ParseContext) parser.ParserErrors {
+	unit := seqReader.options.ColumnUnit
+	trimmed := strings.TrimLeft(record, " \t\r\n")
+	position := unit.Advance(start, record[:len(record)-len(trimmed)])
+	literal, _, _ := strings.Cut(strings.TrimRight(trimmed, " \t\r\n"), "\n")
+	literal = strings.TrimRight(literal, " \t\r")
+	end := unit.Advance(position, literal)
+
+	syntaxError := parser.SyntaxError{
+		Code:      code,
+		Message:   message,
+		Line:      position.Line,
+		Column:    position.Column,
+		Offset:    position.Offset,
+		EndLine:   end.Line,
+		EndColumn: end.Column,
+		EndOffset: end.Offset,
+		Literal:   literal,
+	}
+
+	return parser.ParserErrors{&syntaxError}
+}
+
+// JSONSeqWriter writes values as a JSON text sequence, every value starts
+// with the RS byte and ends with a line feed.
+type JSONSeqWriter struct {
+	encoder *Encoder
+}
+
+func NewJSONSeqWriter(output io.Writer) *JSONSeqWriter {
+	return &JSONSeqWriter{encoder: NewEncoder(output)}
+}
+
+// Write writes v as a single record. Nothing is written when v cannot be
+// encoded.
+func (seqWriter *JSONSeqWriter) Write(v any) error {
+	seqWriter.encoder.writer.WriteByte(RecordSeparator)
+
+	return seqWriter.encoder.Encode(v)
+}
@@ -0,0 +1,142 @@
+package jsonparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"sw/json-parser/parser"
+)
+
+func TestJSONSeqReaderReadsRecords(t *testing.T) {
+	input := "\x1e{\"id\": 1}\n\x1e\n\x1e[1,\n 2]\n\x1e\"three\"\x1e42\n\x1etrue "
+	expected := []string{`map[id:1]`, `[1 2]`, `three`, `42`, `true`}
+
+	reader := NewJSONSeqReader(strings.NewReader(input))
+	for i := range expected {
+		result, err := reader.Read()
+		if err != nil {
+			t.Fatalf("tests[%d] - Read returned an error. Error: %q", i, err)
+		}
+
+		if fmt.Sprint(result.Value) != expected[i] {
+			t.Fatalf("tests[%d] - record is wrong. Expected=%s, but got=%v", i, expected[i], result.Value)
+		}
+	}
+
+	if _, err := reader.Read(); err != io.EOF {
+		t.Fatalf("Expected io.EOF after the last record, but got=%v", err)
+	}
+}
+
+func TestJSONSeqReaderRecoversFromInvalidRecords(t *testing.T) {
+	input := "{\"a\": 0}\n\x1e{\"id\": 1}\n\x1e{\"id\": 2, \"na\x1e{\"id\": 3}\n\x1e123\x1e{\"id\": 4}\n\x1enul\x1enull"
+
+	tests := []struct {
+		expectedValue  string
+		expectedCode   parser.ErrorCode
+		expectedLine   int
+		expectedColumn int
+		expectedOffset int
+		// the end of the span of the error
+		expectedEndOffset int
+	}{
+		{"", CodeMissingRecordSeparator, 1, 1, 0, 8},
+		{`map[id:1]`, "", 0, 0, 0, 0},
+		{"", parser.CodeUnterminatedString, 3, 12, 31, 34},
+		{`map[id:3]`, "", 0, 0, 0, 0},
+		{"", CodeTruncatedValue, 4, 2, 46, 49},
+		{`map[id:4]`, "", 0, 0, 0, 0},
+		{"", parser.CodeUnexpectedToken, 5, 2, 61, 64},
+		{"", CodeTruncatedValue, 5, 6, 65, 69},
+	}
+
+	reader := NewJSONSeqReader(strings.NewReader(input))
+	for i, test := range tests {
+		result, err := reader.Read()
+		if test.expectedCode == "" {
+			if err != nil || fmt.Sprint(result.Value) != test.expectedValue {
+				t.Fatalf("tests[%d] - record is wrong. Expected=%s, but got=%v (error %v)", i, test.expectedValue, result, err)
+			}
+			continue
+		}
+
+		var syntaxError *parser.SyntaxError
+		if errors.As(err, &syntaxError) == false {
+			t.Fatalf("tests[%d] - expected a syntax error, but got=%v", i, err)
+		}
+
+		if syntaxError.Code != test.expectedCode || syntaxError.Line != test.expectedLine || syntaxError.Column != test.expectedColumn || syntaxError.Offset != test.expectedOffset || syntaxError.EndOffset != test.expectedEndOffset {
+			t.Fatalf("tests[%d] - error is wrong. Expected=%s at %d:%d (offset %d-%d), but got=%s at %d:%d (offset %d-%d)", i, test.expectedCode, test.expectedLine, test.expectedColumn, test.expectedOffset, test.expectedEndOffset, syntaxError.Code, syntaxError.Line, syntaxError.Column, syntaxError.Offset, syntaxError.EndOffset)
+		}
+	}
+
+	if _, err := reader.Read(); err != io.EOF {
+		t.Fatalf("Expected io.EOF after the last record, but got=%v", err)
+	}
+}
+
+func TestJSONSeqReaderSkipsInvalidRecords(t *testing.T) {
+	input := "\x1e1\n\x1e[1, 2\x1e2\n\x1e3"
+
+	reader := NewJSONSeqReader(strings.NewReader(input))
+	reader.SkipInvalid = true
+
+	values := []any{}
+	for {
+		result, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Read returned an error. Error: %q", err)
+		}
+		values = append(values, result.Value)
+	}
+
+	if fmt.Sprint(values) != "[1 2]" {
+		t.Fatalf("Wrong records. Expected=[1 2], but got=%v", values)
+	}
+
+	skipped := reader.Errors()
+	if len(skipped) != 2 || skipped[0].Code != parser.CodeUnclosedArray || skipped[1].Code != CodeTruncatedValue {
+		t.Fatalf("Wrong errors of the skipped records. Got=%v", skipped)
+	}
+}
+
+func TestJSONSeqWriterRoundTrip(t *testing.T) {
+	values := []any{map[string]any{"id": 1}, 42, "text"}
+
+	var buffer bytes.Buffer
+	writer := NewJSONSeqWriter(&buffer)
+	for i, value := range values {
+		if err := writer.Write(value); err != nil {
+			t.Fatalf("tests[%d] - Write returned an error. Error: %q", i, err)
+		}
+	}
+
+	if err := writer.Write(func() {}); err == nil {
+		t.Fatalf("Writing an unsupported value did not return an error")
+	}
+
+	expected := "\x1e{\"id\":1}\n\x1e42\n\x1e\"text\"\n"
+	if buffer.String() != expected {
+		t.Fatalf("Output is wrong. Expected=%q, but got=%q", expected, buffer.String())
+	}
+
+	reader := NewJSONSeqReader(&buffer)
+	for i, value := range values {
+		result, err := reader.Read()
+		if err != nil {
+			t.Fatalf("tests[%d] - Read returned an error. Error: %q", i, err)
+		}
+
+		if fmt.Sprint(result.Value) != fmt.Sprint(value) {
+			t.Fatalf("tests[%d] - record is wrong. Expected=%v, but got=%v", i, value, result.Value)
+		}
+	}
+}
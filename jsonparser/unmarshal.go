@@ -0,0 +1,465 @@
+package jsonparser
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"sw/json-parser/lexer"
+	"sw/json-parser/parser"
+	"sw/json-parser/token"
+)
+
+// UnmarshalError describes a value that could not be stored in the Go value
+// passed to Unmarshal. Path is the path of the JSON value, like
+// $.orders[0].price, and Line and Column point at the value in the input.
+type UnmarshalError struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (unmarshalError *UnmarshalError) Error() string {
+	return fmt.Sprintf("cannot unmarshal %s at line %d and column %d: %s", unmarshalError.Path, unmarshalError.Line, unmarshalError.Column, unmarshalError.Message)
+}
+
+// Unmarshal parses the input and stores the result in the value pointed to
+// by v. Structs, slices, arrays, maps, pointers, interfaces and basic types
+// are supported, struct fields honor `json:"name,omitempty"` tags the same
+// way encoding/json does. Syntax errors are returned as parser.ParserErrors.
+func Unmarshal(input string, v any, options ...parser.ParserOptions) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("Unmarshal requires a non-nil pointer, but got %T", v)
+	}
+
+	parserOptions := parser.ParserOptions{}
+	if len(options) > 0 {
+		parserOptions = options[0]
+	}
+
+	// like encoding/json, numbers are decoded from their literal, so that
+	// integers above math.MaxInt64 still fit into a uint64
+	plainNumbers := parserOptions.UseNumber == false && parserOptions.BigNumbers == parser.BigNumbersOff
+	if plainNumbers {
+		parserOptions.UseNumber = true
+	}
+
+	result, parserErrors := parser.New(lexer.New(input), parserOptions).Parse()
+	if parserErrors != nil {
+		return parserErrors
+	}
+
+	decoder := decoder{input: input, options: parserOptions, plainNumbers: plainNumbers}
+	if parserOptions.TrackPositions {
+		decoder.positions = result
+	}
+
+	return decoder.decode(result.Value, target.Elem(), nil)
+}
+
+type decoder struct {
+	input   string
+	options parser.ParserOptions
+	// positions is a result parsed with TrackPositions, which is only needed
+	// to report an error, so it is parsed on the first one
+	positions *parser.ParserResult
+	// set when the input was parsed with UseNumber only for decoding, the
+	// numbers stored in any values are turned back into an int or a float64
+	plainNumbers bool
+}
+
+func (decoder *decoder) error(path []any, format string, args ...any) error {
+	if decoder.positions == nil {
+		options := decoder.options
+		options.TrackPositions = true
+		decoder.positions, _ = parser.New(lexer.New(decoder.input), options).Parse()
+	}
+
+	var position token.Token
+	if decoder.positions != nil {
+		position, _ = decoder.positions.Position(path...)
+	}
+
+	return &UnmarshalError{
+		Path:    parser.FormatPath(path),
+		Line:    position.Line,
+		Column:  position.Column,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (decoder *decoder) decode(value any, target reflect.Value, path []any) error {
+	if target.Kind() == reflect.Pointer {
+		if value == nil {
+			target.SetZero()
+
+			return nil
+		}
+
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+
+		return decoder.decode(value, target.Elem(), path)
+	}
+
+	if target.Kind() == reflect.Interface && target.NumMethod() == 0 {
+		if value == nil {
+			target.SetZero()
+
+			return nil
+		}
+
+		value, err := decoder.plainValue(value, path)
+		if err != nil {
+			return err
+		}
+		target.Set(reflect.ValueOf(value))
+
+		return nil
+	}
+
+	if target.Type() == orderedObjectType {
+		switch val := value.(type) {
+		case *parser.OrderedObject:
+			if _, err := decoder.plainValue(val, path); err != nil {
+				return err
+			}
+			target.Set(reflect.ValueOf(val).Elem())
+
+			return nil
+		case map[string]any:
+			return decoder.error(path, "cannot store object in a value of type %s, the order of the members is only kept with the OrderedObjects option", target.Type())
+		}
+	}
+
+	if target.Type() == numberType {
+		switch val := value.(type) {
+		case int:
+			// small numbers of the BigNumbers option
+			target.SetString(strconv.Itoa(val))
+
+			return nil
+		case string:
+			// like encoding/json, strings are only accepted when they hold a
+			// valid number literal
+			if isValidNumber(val) == false {
+				return decoder.error(path, "string %q is not a valid number for a value of type %s", val, target.Type())
+			}
+			target.SetString(val)
+
+			return nil
+		}
+	}
+
+	switch target.Type() {
+	case bigIntType, bigRatType, bigFloatType:
+		return decoder.decodeBigNumber(value, target, path)
+	}
+
+	switch val := value.(type) {
+	case nil:
+		// like encoding/json, null only resets values that can be nil
+		switch target.Kind() {
+		case reflect.Interface, reflect.Map, reflect.Slice:
+			target.SetZero()
+		}
+
+		return nil
+	case bool:
+		if target.Kind() != reflect.Bool {
+			return decoder.error(path, "cannot store bool in a value of type %s", target.Type())
+		}
+		target.SetBool(val)
+	case string:
+		if target.Kind() == reflect.Slice && target.Type().Elem().Kind() == reflect.Uint8 {
+			// like encoding/json, byte slices are written as base64 strings
+			decoded, err := base64.StdEncoding.DecodeString(val)
+			if err != nil {
+				return decoder.error(path, "string %q is not valid base64 for a value of type %s", val, target.Type())
+			}
+			target.SetBytes(decoded)
+
+			return nil
+		}
+
+		if target.Kind() != reflect.String {
+			return decoder.error(path, "cannot store string in a value of type %s", target.Type())
+		}
+		target.SetString(val)
+	case int:
+		return decoder.decodeInt(val, target, path)
+	case parser.Number:
+		return decoder.decodeNumber(val, target, path)
+	case *big.Int, *big.Rat, *big.Float:
+		literal, err := parser.FormatNumber(val)
+		if err != nil {
+			return decoder.error(path, "%s", err)
+		}
+
+		return decoder.decodeNumber(parser.Number(literal), target, path)
+	case []any:
+		return decoder.decodeArray(val, target, path)
+	case map[string]any:
+		return decoder.decodeObject(val, target, path)
+	case *parser.OrderedObject:
+		// the order does not matter for structs and maps
+		return decoder.decodeObject(val.Map(), target, path)
+	}
+
+	return nil
+}
+
+func (decoder *decoder) decodeInt(number int, target reflect.Value, path []any) error {
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if target.OverflowInt(int64(number)) {
+			return decoder.error(path, "number %d does not fit into a value of type %s", number, target.Type())
+		}
+		target.SetInt(int64(number))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if number < 0 || target.OverflowUint(uint64(number)) {
+			return decoder.error(path, "number %d does not fit into a value of type %s", number, target.Type())
+		}
+		target.SetUint(uint64(number))
+	case reflect.Float32, reflect.Float64:
+		target.SetFloat(float64(number))
+	default:
+		return decoder.error(path, "cannot store number in a value of type %s", target.Type())
+	}
+
+	return nil
+}
+
+func (decoder *decoder) decodeNumber(number parser.Number, target reflect.Value, path []any) error {
+	if target.Type() == numberType {
+		target.SetString(string(number))
+
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		integer, err := strconv.ParseInt(string(number), 10, target.Type().Bits())
+		if err != nil {
+			return decoder.error(path, "number %s does not fit into a value of type %s", number, target.Type())
+		}
+		target.SetInt(integer)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		integer, err := strconv.ParseUint(string(number), 10, target.Type().Bits())
+		if err != nil {
+			return decoder.error(path, "number %s does not fit into a value of type %s", number, target.Type())
+		}
+		target.SetUint(integer)
+	case reflect.Float32, reflect.Float64:
+		float, err := strconv.ParseFloat(string(number), target.Type().Bits())
+		if err != nil {
+			return decoder.error(path, "number %s does not fit into a value of type %s", number, target.Type())
+		}
+		target.SetFloat(float)
+	default:
+		return decoder.error(path, "cannot store number in a value of type %s", target.Type())
+	}
+
+	return nil
+}
+
+// plainValue turns the numbers of a value parsed with UseNumber only for
+// decoding back into an int or a float64, like the parser produces them
+// without the option. Arrays and objects are converted in place.
+func (decoder *decoder) plainValue(value any, path []any) (any, error) {
+	if decoder.plainNumbers == false {
+		return value, nil
+	}
+
+	switch val := value.(type) {
+	case parser.Number:
+		if integer, err := strconv.Atoi(string(val)); err == nil {
+			return integer, nil
+		}
+
+		float, err := strconv.ParseFloat(string(val), 64)
+		if err != nil {
+			return nil, decoder.error(path, "number %s does not fit into a value of type float64", val)
+		}
+
+		return float, nil
+	case []any:
+		for idx, element := range val {
+			converted, err := decoder.plainValue(element, append(path, idx))
+			if err != nil {
+				return nil, err
+			}
+			val[idx] = converted
+		}
+	case map[string]any:
+		for key, element := range val {
+			converted, err := decoder.plainValue(element, append(path, key))
+			if err != nil {
+				return nil, err
+			}
+			val[key] = converted
+		}
+	case *parser.OrderedObject:
+		for _, key := range val.Keys() {
+			element, _ := val.Get(key)
+			converted, err := decoder.plainValue(element, append(path, key))
+			if err != nil {
+				return nil, err
+			}
+			val.Set(key, converted)
+		}
+	}
+
+	return value, nil
+}
+
+// decodeBigNumber stores any number in a big.Int, big.Rat or big.Float. Only
+// the big.Float target may round the number.
+func (decoder *decoder) decodeBigNumber(value any, target reflect.Value, path []any) error {
+	if value == nil {
+		return nil
+	}
+
+	rat, err := parser.NumberToRat(value)
+	if err != nil {
+		return decoder.error(path, "cannot store %T in a value of type %s", value, target.Type())
+	}
+
+	switch number := target.Addr().Interface().(type) {
+	case *big.Int:
+		if rat.IsInt() == false {
+			literal, _ := parser.FormatNumber(value)
+
+			return decoder.error(path, "number %s does not fit into a value of type %s", literal, target.Type())
+		}
+		number.Set(rat.Num())
+	case *big.Rat:
+		number.Set(rat)
+	case *big.Float:
+		if float, ok := value.(*big.Float); ok {
+			number.Set(float)
+		} else {
+			number.SetRat(rat)
+		}
+	}
+
+	return nil
+}
+
+func (decoder *decoder) decodeArray(array []any, target reflect.Value, path []any) error {
+	switch target.Kind() {
+	case reflect.Slice:
+		target.Set(reflect.MakeSlice(target.Type(), len(array), len(array)))
+	case reflect.Array:
+		// like encoding/json, additional values are dropped and missing ones zeroed
+		target.SetZero()
+	default:
+		return decoder.error(path, "cannot store array in a value of type %s", target.Type())
+	}
+
+	for idx, element := range array {
+		if idx >= target.Len() {
+			break
+		}
+
+		if err := decoder.decode(element, target.Index(idx), append(path, idx)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (decoder *decoder) decodeObject(object map[string]any, target reflect.Value, path []any) error {
+	switch target.Kind() {
+	case reflect.Struct:
+		fields := structFields(target.Type())
+		for key, value := range object {
+			field, wasFound := fieldByName(fields, key)
+			if wasFound == false {
+				// like encoding/json, unknown keys are ignored
+				continue
+			}
+
+			fieldValue, err := fieldByIndex(target, field.index)
+			if err != nil {
+				return decoder.error(append(path, key), "%s", err)
+			}
+
+			if err := decoder.decode(value, fieldValue, append(path, key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		mapType := target.Type()
+		if target.IsNil() {
+			target.Set(reflect.MakeMapWithSize(mapType, len(object)))
+		}
+
+		for key, value := range object {
+			mapKey, err := decodeMapKey(key, mapType.Key())
+			if err != nil {
+				return decoder.error(append(path, key), "%s", err)
+			}
+
+			mapValue := reflect.New(mapType.Elem()).Elem()
+			if err := decoder.decode(value, mapValue, append(path, key)); err != nil {
+				return err
+			}
+			target.SetMapIndex(mapKey, mapValue)
+		}
+	default:
+		return decoder.error(path, "cannot store object in a value of type %s", target.Type())
+	}
+
+	return nil
+}
+
+// fieldByIndex works like reflect.Value.FieldByIndex, but allocates nil
+// pointers to embedded structs on the way.
+func fieldByIndex(structValue reflect.Value, index []int) (reflect.Value, error) {
+	for idx, fieldIdx := range index {
+		if idx > 0 && structValue.Kind() == reflect.Pointer {
+			if structValue.IsNil() {
+				if structValue.CanSet() == false {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %s", structValue.Type().Elem())
+				}
+				structValue.Set(reflect.New(structValue.Type().Elem()))
+			}
+			structValue = structValue.Elem()
+		}
+		structValue = structValue.Field(fieldIdx)
+	}
+
+	return structValue, nil
+}
+
+func decodeMapKey(key string, keyType reflect.Type) (reflect.Value, error) {
+	mapKey := reflect.New(keyType).Elem()
+
+	switch keyType.Kind() {
+	case reflect.String:
+		mapKey.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(key, 10, keyType.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("key %q is not a valid %s", key, keyType)
+		}
+		mapKey.SetInt(number)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		number, err := strconv.ParseUint(key, 10, keyType.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("key %q is not a valid %s", key, keyType)
+		}
+		mapKey.SetUint(number)
+	default:
+		return reflect.Value{}, fmt.Errorf("map keys of type %s are not supported", keyType)
+	}
+
+	return mapKey, nil
+}
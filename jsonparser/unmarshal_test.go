@@ -0,0 +1,333 @@
+package jsonparser
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"sw/json-parser/parser"
+)
+
+type address struct {
+	City string `json:"city"`
+	Zip  *string
+}
+
+type audit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type order struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price,omitempty"`
+	Count uint8   `json:"count"`
+}
+
+type customer struct {
+	audit
+	Name     string            `json:"name"`
+	Age      int               `json:"age"`
+	Active   bool              `json:"active"`
+	Address  *address          `json:"address"`
+	Orders   []order           `json:"orders"`
+	Tags     [2]string         `json:"tags"`
+	Scores   map[string]int    `json:"scores"`
+	ByID     map[int]string    `json:"by_id"`
+	Extra    any               `json:"extra"`
+	Ignored  string            `json:"-"`
+	Nickname string            `json:"nickname,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	internal string
+}
+
+func TestUnmarshalStruct(t *testing.T) {
+	input := `{
+		"created_by": "admin",
+		"name": "Joe",
+		"AGE": 88,
+		"active": true,
+		"address": {"city": "Oslo", "Zip": null},
+		"orders": [{"name": "book", "price": 11.99, "count": 2}, {"name": "ball", "count": 1}],
+		"tags": ["a", "b", "c"],
+		"scores": {"math": 5},
+		"by_id": {"12": "twelve"},
+		"extra": [1, "two"],
+		"-": "not ignored?",
+		"Ignored": "still ignored",
+		"labels": null,
+		"unknown": {"is": "skipped"}
+	}`
+
+	var result customer
+	result.Labels = map[string]string{"reset": "by null"}
+	if err := Unmarshal(input, &result); err != nil {
+		t.Fatalf("Unmarshal returned an error. Error: %q", err)
+	}
+
+	expected := customer{
+		audit:   audit{CreatedBy: "admin"},
+		Name:    "Joe",
+		Age:     88,
+		Active:  true,
+		Address: &address{City: "Oslo"},
+		Orders:  []order{{Name: "book", Price: 11.99, Count: 2}, {Name: "ball", Count: 1}},
+		Tags:    [2]string{"a", "b"},
+		Scores:  map[string]int{"math": 5},
+		ByID:    map[int]string{12: "twelve"},
+		Extra:   []any{1, "two"},
+	}
+
+	if reflect.DeepEqual(result, expected) == false {
+		t.Fatalf("Unexpected result.\nExpected %+v\nbut got  %+v", expected, result)
+	}
+}
+
+func TestUnmarshalBasicTypes(t *testing.T) {
+	var number float32
+	if err := Unmarshal(`12.5`, &number); err != nil || number != 12.5 {
+		t.Fatalf("Unmarshal returned %f, %v", number, err)
+	}
+
+	var values []*int
+	if err := Unmarshal(`[1, null, 3]`, &values); err != nil || len(values) != 3 || *values[0] != 1 || values[1] != nil || *values[2] != 3 {
+		t.Fatalf("Unmarshal returned %v, %v", values, err)
+	}
+
+	var anything any
+	if err := Unmarshal(`{"a": [true]}`, &anything); err != nil {
+		t.Fatalf("Unmarshal returned an error. Error: %q", err)
+	}
+
+	if reflect.DeepEqual(anything, map[string]any{"a": []any{true}}) == false {
+		t.Fatalf("Unexpected result %v", anything)
+	}
+}
+
+func TestUnmarshalByteSlices(t *testing.T) {
+	var data struct {
+		Raw     []byte `json:"raw"`
+		Numbers []byte `json:"numbers"`
+	}
+
+	if err := Unmarshal(`{"raw": "aGk=", "numbers": [104, 105]}`, &data); err != nil {
+		t.Fatalf("Unmarshal returned an error. Error: %q", err)
+	}
+
+	if string(data.Raw) != "hi" || string(data.Numbers) != "hi" {
+		t.Fatalf("Unexpected bytes %q and %q", data.Raw, data.Numbers)
+	}
+
+	err := Unmarshal(`{"raw": "not base64!"}`, &data)
+	var unmarshalError *UnmarshalError
+	if errors.As(err, &unmarshalError) == false || unmarshalError.Path != "$.raw" {
+		t.Fatalf("Expected an UnmarshalError at $.raw, but got=%v", err)
+	}
+}
+
+func TestUnmarshalErrorsCarryPathAndPosition(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`{"name": 12}`, "cannot unmarshal $.name at line 1 and column 10: cannot store number in a value of type string"},
+		{"{\"orders\": [\n  {\"name\": \"book\", \"count\": 300}\n]}", "cannot unmarshal $.orders[0].count at line 2 and column 29: number 300 does not fit into a value of type uint8"},
+		{`{"age": 1.5}`, "cannot unmarshal $.age at line 1 and column 9: number 1.5 does not fit into a value of type int"},
+		{`{"by_id": {"x": "y"}}`, `cannot unmarshal $.by_id.x at line 1 and column 18: key "x" is not a valid int`},
+		{`{"orders": {"name": "book"}}`, "cannot unmarshal $.orders at line 1 and column 12: cannot store object in a value of type []jsonparser.order"},
+		{`[]`, "cannot unmarshal $ at line 1 and column 1: cannot store array in a value of type jsonparser.customer"},
+	}
+
+	for i, test := range tests {
+		var result customer
+		err := Unmarshal(test.input, &result)
+
+		var unmarshalError *UnmarshalError
+		if errors.As(err, &unmarshalError) == false {
+			t.Fatalf("tests[%d] - expected an unmarshal error, but got %v", i, err)
+		}
+
+		if err.Error() != test.expectedError {
+			t.Fatalf("tests[%d] - error is wrong.\nExpected=%q\nbut got= %q", i, test.expectedError, err.Error())
+		}
+	}
+}
+
+func TestUnmarshalReturnsSyntaxErrors(t *testing.T) {
+	var result customer
+	err := Unmarshal(`{"name": "Joe",}`, &result, parser.ParserOptions{Strict: true})
+
+	if errors.Is(err, parser.CodeTrailingComma) == false {
+		t.Fatalf("Expected a trailing comma error, but got %v", err)
+	}
+
+	if err := Unmarshal(`{}`, result); err == nil {
+		t.Fatalf("Unmarshal into a non-pointer did not fail")
+	}
+}
+
+func TestUnmarshalOrderedObjects(t *testing.T) {
+	type document struct {
+		Name     string               `json:"name"`
+		Settings parser.OrderedObject `json:"settings"`
+		Extra    any                  `json:"extra"`
+	}
+
+	input := `{"name": "app", "settings": {"b": 1, "a": {"d": 2, "c": 3}}, "extra": {"z": 1, "y": 2}}`
+
+	var doc document
+	if err := Unmarshal(input, &doc, parser.ParserOptions{OrderedObjects: true}); err != nil {
+		t.Fatalf("Unmarshal returned an error. Error: %q", err)
+	}
+
+	if doc.Name != "app" || doc.Settings.String() != "{b:1 a:{d:2 c:3}}" {
+		t.Fatalf("Unexpected document %+v", doc)
+	}
+
+	extra, ok := doc.Extra.(*parser.OrderedObject)
+	if ok == false || extra.String() != "{z:1 y:2}" {
+		t.Fatalf("Unexpected extra value %v", doc.Extra)
+	}
+
+	err := Unmarshal(input, &doc)
+	var unmarshalError *UnmarshalError
+	if errors.As(err, &unmarshalError) == false || unmarshalError.Path != "$.settings" {
+		t.Fatalf("Expected an UnmarshalError at $.settings, but got=%v", err)
+	}
+}
+
+func TestUnmarshalNumbers(t *testing.T) {
+	type payment struct {
+		ID     uint64        `json:"id"`
+		Amount parser.Number `json:"amount"`
+		Fee    float32       `json:"fee"`
+		Count  int8          `json:"count"`
+		Raw    any           `json:"raw"`
+	}
+
+	input := `{"id": 18446744073709551615, "amount": 10.10, "fee": 0.25, "count": 7, "raw": 1e400}`
+
+	var p payment
+	if err := Unmarshal(input, &p, parser.ParserOptions{UseNumber: true}); err != nil {
+		t.Fatalf("Unmarshal returned an error. Error: %q", err)
+	}
+
+	expected := payment{ID: 18446744073709551615, Amount: "10.10", Fee: 0.25, Count: 7, Raw: parser.Number("1e400")}
+	if reflect.DeepEqual(p, expected) == false {
+		t.Fatalf("Unexpected payment. Expected=%+v, but got=%+v", expected, p)
+	}
+
+	// a Number field is also filled without the UseNumber option
+	var plain payment
+	if err := Unmarshal(`{"amount": 10.5}`, &plain); err != nil || plain.Amount != "10.5" {
+		t.Fatalf("Unexpected amount %q, %v", plain.Amount, err)
+	}
+
+	err := Unmarshal(`{"count": 300}`, &p, parser.ParserOptions{UseNumber: true})
+	var unmarshalError *UnmarshalError
+	if errors.As(err, &unmarshalError) == false || unmarshalError.Path != "$.count" {
+		t.Fatalf("Expected an UnmarshalError at $.count, but got=%v", err)
+	}
+
+	// strings are only stored in a Number when they hold a valid number
+	if err := Unmarshal(`{"amount": "12.50"}`, &plain); err != nil || plain.Amount != "12.50" {
+		t.Fatalf("Unexpected amount %q, %v", plain.Amount, err)
+	}
+
+	for _, input := range []string{`{"amount": "hello"}`, `{"amount": " 1 "}`, `{"amount": "1\n"}`} {
+		err = Unmarshal(input, &plain)
+		if errors.As(err, &unmarshalError) == false || unmarshalError.Path != "$.amount" {
+			t.Fatalf("Expected an UnmarshalError at $.amount for %s, but got=%v", input, err)
+		}
+	}
+}
+
+func TestUnmarshalNumbersLikeEncodingJson(t *testing.T) {
+	var unsigned uint64
+	if err := Unmarshal(`18446744073709551615`, &unsigned); err != nil || unsigned != 18446744073709551615 {
+		t.Fatalf("Unmarshal returned %d, %v", unsigned, err)
+	}
+
+	var values []any
+	if err := Unmarshal(`[1, 2.5, {"id": 18446744073709551615}]`, &values); err != nil {
+		t.Fatalf("Unmarshal returned an error. Error: %q", err)
+	}
+
+	expected := []any{1, 2.5, map[string]any{"id": 1.8446744073709552e19}}
+	if reflect.DeepEqual(values, expected) == false {
+		t.Fatalf("Unexpected values. Expected=%v, but got=%v", expected, values)
+	}
+
+	// the caller's UseNumber option is kept for any values
+	if err := Unmarshal(`[1, 2.5]`, &values, parser.ParserOptions{UseNumber: true}); err != nil || reflect.DeepEqual(values, []any{parser.Number("1"), parser.Number("2.5")}) == false {
+		t.Fatalf("Unmarshal returned %v, %v", values, err)
+	}
+
+	tests := []struct {
+		input  string
+		target any
+		path   string
+	}{
+		{`[1e400]`, &values, "$[0]"},
+		{`{"a": [18446744073709551616]}`, &map[string][]uint64{}, "$.a[0]"},
+		{`[-1]`, &[]uint{}, "$[0]"},
+		{`[3e2]`, &[]int{}, "$[0]"},
+	}
+
+	for i, test := range tests {
+		err := Unmarshal(test.input, test.target)
+		var unmarshalError *UnmarshalError
+		if errors.As(err, &unmarshalError) == false || unmarshalError.Path != test.path {
+			t.Fatalf("tests[%d] - expected an UnmarshalError at %s, but got=%v", i, test.path, err)
+		}
+	}
+}
+
+func TestUnmarshalBigNumbers(t *testing.T) {
+	type invoice struct {
+		Total  big.Rat   `json:"total"`
+		Serial *big.Int  `json:"serial"`
+		Rate   big.Float `json:"rate"`
+		Count  int       `json:"count"`
+		Amount float64   `json:"amount"`
+	}
+
+	input := `{"total": 1234567890.1234567890123, "serial": 123456789012345678901234567890, "rate": 0.5, "count": 3e2, "amount": 0.25}`
+
+	var plain struct {
+		Total big.Rat `json:"total"`
+	}
+	if err := Unmarshal(input, &plain); err != nil {
+		t.Fatalf("Unmarshal returned an error. Error: %q", err)
+	}
+
+	// numbers are decoded from their literal, so the total is exact without
+	// the BigNumbers option as well
+	if total, _ := parser.FormatNumber(&plain.Total); total != "1234567890.1234567890123" {
+		t.Fatalf("Total is wrong. Expected=1234567890.1234567890123, but got=%s", plain.Total.String())
+	}
+
+	var exact invoice
+	if err := Unmarshal(input, &exact, parser.ParserOptions{BigNumbers: parser.BigNumbersRat}); err != nil {
+		t.Fatalf("Unmarshal returned an error. Error: %q", err)
+	}
+
+	if total, _ := parser.FormatNumber(&exact.Total); total != "1234567890.1234567890123" {
+		t.Fatalf("Total is wrong. Expected=1234567890.1234567890123, but got=%s", total)
+	}
+
+	if exact.Serial.String() != "123456789012345678901234567890" || exact.Rate.Text('g', -1) != "0.5" || exact.Count != 300 || exact.Amount != 0.25 {
+		t.Fatalf("Unexpected invoice %+v", exact)
+	}
+
+	err := Unmarshal(`{"serial": 1.5}`, &exact)
+	var unmarshalError *UnmarshalError
+	if errors.As(err, &unmarshalError) == false || unmarshalError.Path != "$.serial" {
+		t.Fatalf("Expected an UnmarshalError at $.serial, but got=%v", err)
+	}
+
+	err = Unmarshal(`{"count": 1e30}`, &exact, parser.ParserOptions{BigNumbers: parser.BigNumbersRat})
+	if errors.As(err, &unmarshalError) == false || unmarshalError.Path != "$.count" {
+		t.Fatalf("Expected an UnmarshalError at $.count, but got=%v", err)
+	}
+}
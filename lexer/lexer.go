@@ -1,60 +1,552 @@
 package lexer
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
 	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"sw/json-parser/token"
 )
 
+// ParseContext tracks the position of the current character. Offset is the
+// zero-based byte offset into the input, Column is counted in the ColumnUnit
+// of the lexer.
 type ParseContext struct {
 	Line   int
 	Column int
+	Offset int
 }
 
-func newParseContext() *ParseContext {
-	return &ParseContext{Line: 1, Column: 0}
+// ColumnUnit decides what the columns of the positions count.
+type ColumnUnit int
+
+const (
+	// ColumnBytes counts every byte of the input as a column.
+	ColumnBytes ColumnUnit = iota
+	// ColumnCodePoints counts every Unicode code point as a column.
+	ColumnCodePoints
+	// ColumnUTF16 counts UTF-16 code units, like most editors and the
+	// Language Server Protocol do, so characters outside of the Basic
+	// Multilingual Plane take two columns.
+	ColumnUTF16
+)
+
+// width returns the amount of columns passed by moving past the byte. The
+// bytes following the first byte of a UTF-8 sequence take no columns in the
+// units other than ColumnBytes.
+func (unit ColumnUnit) width(char byte) int {
+	switch {
+	case unit == ColumnBytes || char < utf8.RuneSelf:
+		return 1
+	case utf8.RuneStart(char) == false:
+		return 0
+	case unit == ColumnUTF16 && char >= 0xF0:
+		return 2
+	}
+
+	return 1
+}
+
+// Advance returns the position right after the text, which starts at the
+// given position.
+func (unit ColumnUnit) Advance(position ParseContext, text string) ParseContext {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			position.Line += 1
+			position.Column = 1
+		} else {
+			position.Column += unit.width(text[i])
+		}
+	}
+	position.Offset += len(text)
+
+	return position
+}
+
+const (
+	CodeInvalidEscape      = "invalid_escape"
+	CodeInvalidSurrogate   = "invalid_surrogate"
+	CodeControlCharacter   = "control_character"
+	CodeUnterminatedString = "unterminated_string"
+	CodeInvalidNumber      = "invalid_number"
+	CodeInputTooLarge      = "input_too_large"
+	CodeStringTooLong      = "string_too_long"
+	CodeNumberTooLong      = "number_too_long"
+	CodeInvalidUTF8        = "invalid_utf8"
+	CodeUnexpectedBOM      = "unexpected_bom"
+	CodeReadError          = "read_error"
+)
+
+// byteOrderMark is skipped at the start of the input, some editors on Windows
+// put it at the start of every UTF-8 file.
+const byteOrderMark = "\uFEFF"
+
+// LexerError describes a malformed piece of input, like an invalid escape
+// sequence, found while tokenizing. The end position points right after the
+// malformed input, it equals the start for errors at the end of the input.
+type LexerError struct {
+	Code      string
+	Message   string
+	Line      int
+	Column    int
+	Offset    int
+	EndLine   int
+	EndColumn int
+	EndOffset int
+}
+
+// Limits caps the resources spent on the input, so that a hostile payload
+// gets rejected before it is read into memory. A limit of 0 means no limit.
+type Limits struct {
+	// MaxInputSize is the maximum amount of bytes read from the input.
+	MaxInputSize int
+	// MaxStringLength is the maximum length of a decoded string in bytes.
+	MaxStringLength int
+	// MaxNumberLength is the maximum amount of characters of a number.
+	MaxNumberLength int
 }
 
 type Lexer struct {
-	input       string
+	reader io.ByteReader
+	// position is the offset of the next byte read from the reader
 	position    int
 	currentChar byte
+	atEnd       bool
 	context     *ParseContext
+	errors      []LexerError
+	limits      Limits
+	columnUnit  ColumnUnit
+
+	// how invalid UTF-8, a byte order mark at the start and whitespace
+	// outside of RFC 8259 are handled
+	replaceInvalidUTF8 bool
+	rejectBOM          bool
+	strictWhitespace   bool
+
+	// size is the amount of bytes read from the input so far
+	size          int
+	inputTooLarge bool
+
+	// the characters of a keyword or a number are collected in literal while
+	// recording is set, up to one character more than maxLiteral
+	literal    []byte
+	recording  bool
+	maxLiteral int
 }
 
 func New(input string) *Lexer {
-	l := Lexer{input: input, context: newParseContext()}
+	return newLexer(strings.NewReader(input), ParseContext{Line: 1, Column: 1, Offset: 0})
+}
+
+// NewAt creates a lexer for an input that is a part of a bigger document,
+// like a single record of a JSON Lines file. The first character of the
+// input is at the given position of the document, so that the positions of
+// the tokens and errors point into the whole document.
+func NewAt(input string, position ParseContext) *Lexer {
+	return newLexer(strings.NewReader(input), position)
+}
+
+// NewReader creates a lexer reading the input from the reader through a small
+// buffer, which is refilled as the tokens are read. Only the token being read
+// is kept in memory, so documents of any size can be tokenized.
+func NewReader(reader io.Reader) *Lexer {
+	byteReader, ok := reader.(io.ByteReader)
+	if ok == false {
+		byteReader = bufio.NewReader(reader)
+	}
+
+	return newLexer(byteReader, ParseContext{Line: 1, Column: 1, Offset: 0})
+}
+
+func newLexer(reader io.ByteReader, position ParseContext) *Lexer {
+	// readChar moves the context to the first character
+	context := ParseContext{Line: position.Line, Column: position.Column - 1, Offset: position.Offset - 1}
+	l := Lexer{reader: reader, position: position.Offset, context: &context}
 	l.readChar()
 
 	return &l
 }
 
+// SetLimits sets the limits checked while reading the input.
+func (l *Lexer) SetLimits(limits Limits) {
+	l.limits = limits
+}
+
+// SetReplaceInvalidUTF8 makes the lexer replace invalid UTF-8 byte sequences
+// inside of strings with U+FFFD instead of reporting them as CodeInvalidUTF8.
+func (l *Lexer) SetReplaceInvalidUTF8(replace bool) {
+	l.replaceInvalidUTF8 = replace
+}
+
+// SetRejectBOM makes the lexer report a byte order mark at the start of the
+// input as CodeUnexpectedBOM, by default it is skipped silently.
+func (l *Lexer) SetRejectBOM(reject bool) {
+	l.rejectBOM = reject
+}
+
+// SetStrictWhitespace makes the lexer only accept the whitespace of RFC 8259,
+// which is space, tab, line feed and carriage return. By default vertical
+// tabs and form feeds are skipped as well.
+func (l *Lexer) SetStrictWhitespace(strict bool) {
+	l.strictWhitespace = strict
+}
+
+// SetColumnUnit sets the unit of the columns of the tokens and errors read
+// from now on. The column of the current character is kept as it is.
+func (l *Lexer) SetColumnUnit(unit ColumnUnit) {
+	l.columnUnit = unit
+}
+
 func (l *Lexer) readChar() {
-	if l.position >= len(l.input) {
-		// NOTE: 0 corresponds to a space character and will be used later to catch an EOF
+	if l.recording && l.atEnd == false && (l.maxLiteral == 0 || len(l.literal) <= l.maxLiteral) {
+		l.literal = append(l.literal, l.currentChar)
+	}
+
+	l.context.Column += l.columnUnit.width(l.currentChar)
+	l.context.Offset = l.position
+
+	if l.atEnd {
+		return
+	}
+
+	char, err := l.reader.ReadByte()
+	if err != nil {
+		if errors.Is(err, io.EOF) == false {
+			l.addError(CodeReadError, fmt.Sprintf("Reading the input failed: %s.", err), *l.context, *l.context)
+		}
+
+		// NOTE: 0 is only a placeholder, use isAtEnd to check for the end of the input
 		l.currentChar = 0
-	} else {
-		l.currentChar = l.input[l.position]
+		l.atEnd = true
+
+		return
 	}
 
-	l.context.Column += 1
+	l.currentChar = char
 	l.position += 1
+	l.size += 1
+
+	if l.limits.MaxInputSize > 0 && l.size > l.limits.MaxInputSize {
+		l.addError(CodeInputTooLarge, fmt.Sprintf("Input is larger than the limit of %d bytes.", l.limits.MaxInputSize), *l.context, *l.context)
+		// the rest of the input is not read
+		l.inputTooLarge = true
+		l.currentChar = 0
+		l.atEnd = true
+	}
+}
+
+// isAtEnd reports whether the whole input was already consumed. It has to be
+// used instead of comparing the current character with 0, since a NUL byte
+// may be a part of the input.
+func (l *Lexer) isAtEnd() bool {
+	return l.atEnd
+}
+
+// startLiteral starts collecting the characters passed by readChar, starting
+// with the current one. With maxLiteral other than 0, only one character more
+// than maxLiteral is collected, which is enough to tell that it was exceeded.
+func (l *Lexer) startLiteral(maxLiteral int) {
+	l.literal = l.literal[:0]
+	l.recording = true
+	l.maxLiteral = maxLiteral
+}
+
+func (l *Lexer) endLiteral() string {
+	l.recording = false
+
+	return string(l.literal)
+}
+
+// afterCurrentChar returns the position right after the current character,
+// which may span several bytes.
+func (l *Lexer) afterCurrentChar() ParseContext {
+	end := *l.context
+	if l.isAtEnd() {
+		return end
+	}
+
+	size := sequenceLength(l.currentChar)
+	end.Offset += size
+	end.Column += l.columnUnit.width(l.currentChar)
+	if l.columnUnit == ColumnBytes {
+		end.Column += size - 1
+	}
+
+	return end
+}
+
+func (l *Lexer) addError(code string, message string, start ParseContext, end ParseContext) {
+	if l.inputTooLarge {
+		// the token cut off at the limit is not reported on its own
+		return
+	}
+
+	lexerError := LexerError{
+		Code:      code,
+		Message:   message,
+		Line:      start.Line,
+		Column:    start.Column,
+		Offset:    start.Offset,
+		EndLine:   end.Line,
+		EndColumn: end.Column,
+		EndOffset: end.Offset,
+	}
+	l.errors = append(l.errors, lexerError)
+}
+
+// TakeErrors returns the errors collected since the last call and clears them.
+func (l *Lexer) TakeErrors() []LexerError {
+	lexerErrors := l.errors
+	l.errors = nil
+
+	return lexerErrors
 }
 
 func (l *Lexer) readJsonString() string {
+	var builder strings.Builder
+	start := *l.context
+	// the beginning of a string that exceeds MaxStringLength, the rest of it
+	// is only read through without keeping it
+	truncated := ""
+	tooLong := false
+
+	// consume the opening '"'
 	l.readChar()
-	startPosition := l.position
 	for l.currentChar != '"' {
+		switch {
+		case l.isAtEnd():
+			l.addError(CodeUnterminatedString, fmt.Sprintf("Unterminated string starting at line %d column %d.", start.Line, start.Column), start, *l.context)
+
+			if tooLong {
+				return truncated
+			}
+
+			return builder.String()
+		case l.currentChar == '\\':
+			escapeStart := *l.context
+			// consume '\'
+			l.readChar()
+			l.readEscapedChar(&builder, escapeStart)
+		case l.currentChar < 0x20:
+			l.addError(CodeControlCharacter, fmt.Sprintf("Control character %q has to be escaped inside of a string.", l.currentChar), *l.context, l.afterCurrentChar())
+			l.readChar()
+		case l.currentChar >= utf8.RuneSelf:
+			l.readStringCharacter(&builder)
+		default:
+			builder.WriteByte(l.currentChar)
+			l.readChar()
+		}
+
+		if l.limits.MaxStringLength > 0 && builder.Len() > l.limits.MaxStringLength {
+			if tooLong == false {
+				l.addError(CodeStringTooLong, fmt.Sprintf("String is longer than the limit of %d bytes.", l.limits.MaxStringLength), start, *l.context)
+				truncated = builder.String()[:l.limits.MaxStringLength]
+				tooLong = true
+			}
+			builder.Reset()
+		}
+	}
+
+	if tooLong {
+		return truncated
+	}
+
+	return builder.String()
+}
+
+// readStringCharacter copies a character encoded with more than one byte into
+// the string, invalid byte sequences are replaced with U+FFFD.
+func (l *Lexer) readStringCharacter(builder *strings.Builder) {
+	start := *l.context
+	sequence, ok := l.readUTF8Sequence()
+	if ok {
+		builder.WriteString(sequence)
+
+		return
+	}
+
+	if l.replaceInvalidUTF8 == false {
+		l.addError(CodeInvalidUTF8, fmt.Sprintf("Invalid UTF-8 byte sequence %q inside of a string.", sequence), start, *l.context)
+	}
+	builder.WriteRune(utf8.RuneError)
+}
+
+// readUTF8Sequence reads the character starting at the current byte and
+// reports whether it is valid UTF-8. An invalid sequence is read up to the
+// first byte that cannot continue it and takes a single column, like the
+// U+FFFD editors show in its place.
+func (l *Lexer) readUTF8Sequence() (string, bool) {
+	start := *l.context
+	length := sequenceLength(l.currentChar)
+	sequence := []byte{l.currentChar}
+
+	l.readChar()
+	for len(sequence) < length && l.isAtEnd() == false && utf8.RuneStart(l.currentChar) == false {
+		sequence = append(sequence, l.currentChar)
+		l.readChar()
+	}
+
+	if len(sequence) == length && utf8.Valid(sequence) {
+		return string(sequence), true
+	}
+
+	if l.columnUnit != ColumnBytes {
+		l.context.Column = start.Column + 1
+	}
+
+	return string(sequence), false
+}
+
+// sequenceLength returns the length of the UTF-8 sequence the byte starts.
+func sequenceLength(char byte) int {
+	switch {
+	case char >= 0xF0:
+		return 4
+	case char >= 0xE0:
+		return 3
+	case char >= 0xC0:
+		return 2
+	}
+
+	return 1
+}
+
+// readEscapedChar decodes the character following a backslash and leaves the
+// lexer on the first character after the whole escape sequence.
+func (l *Lexer) readEscapedChar(builder *strings.Builder, start ParseContext) {
+	if l.isAtEnd() {
+		// the unterminated string gets reported by readJsonString
+		return
+	}
+
+	switch l.currentChar {
+	case '"', '\\', '/':
+		builder.WriteByte(l.currentChar)
+	case 'b':
+		builder.WriteByte('\b')
+	case 'f':
+		builder.WriteByte('\f')
+	case 'n':
+		builder.WriteByte('\n')
+	case 'r':
+		builder.WriteByte('\r')
+	case 't':
+		builder.WriteByte('\t')
+	case 'u':
+		l.readUnicodeEscape(builder, start)
+
+		return
+	default:
+		l.addError(CodeInvalidEscape, fmt.Sprintf("Invalid escape sequence '\\%c' inside of a string.", l.currentChar), start, l.afterCurrentChar())
+		builder.WriteRune(utf8.RuneError)
+	}
+
+	l.readChar()
+}
+
+// readUnicodeEscape decodes a '\uXXXX' escape, combining UTF-16 surrogate
+// pairs into a single code point.
+func (l *Lexer) readUnicodeEscape(builder *strings.Builder, start ParseContext) {
+	codePoint, ok := l.readHexQuad(start)
+	if ok == false {
+		builder.WriteRune(utf8.RuneError)
+
+		return
+	}
+
+	if utf16.IsSurrogate(codePoint) == false {
+		builder.WriteRune(codePoint)
+
+		return
+	}
+
+	if codePoint >= 0xDC00 {
+		l.addError(CodeInvalidSurrogate, fmt.Sprintf("Unexpected low surrogate '\\u%04X' without a preceding high surrogate.", codePoint), start, *l.context)
+		builder.WriteRune(utf8.RuneError)
+
+		return
+	}
+
+	if l.isAtEnd() {
+		builder.WriteRune(utf8.RuneError)
+
+		return
+	}
+
+	if l.currentChar != '\\' {
+		l.addError(CodeInvalidSurrogate, fmt.Sprintf("High surrogate '\\u%04X' has to be followed by a low surrogate.", codePoint), start, *l.context)
+		builder.WriteRune(utf8.RuneError)
+
+		return
+	}
+
+	lowStart := *l.context
+	// consume '\'
+	l.readChar()
+	if l.currentChar != 'u' {
+		l.addError(CodeInvalidSurrogate, fmt.Sprintf("High surrogate '\\u%04X' has to be followed by a low surrogate.", codePoint), start, l.afterCurrentChar())
+		builder.WriteRune(utf8.RuneError)
+		l.readEscapedChar(builder, lowStart)
+
+		return
+	}
+
+	lowSurrogate, ok := l.readHexQuad(lowStart)
+	if ok == false {
+		builder.WriteRune(utf8.RuneError)
+
+		return
+	}
+
+	decoded := utf16.DecodeRune(codePoint, lowSurrogate)
+	if decoded == utf8.RuneError {
+		l.addError(CodeInvalidSurrogate, fmt.Sprintf("High surrogate '\\u%04X' has to be followed by a low surrogate, but got '\\u%04X'.", codePoint, lowSurrogate), start, *l.context)
+	}
+	builder.WriteRune(decoded)
+}
+
+// readHexQuad reads the four hex digits following '\u'. The lexer is left
+// after the last digit, or on the first character that is not a hex digit.
+func (l *Lexer) readHexQuad(start ParseContext) (rune, bool) {
+	var codePoint rune
+
+	// consume 'u'
+	l.readChar()
+	for range 4 {
+		var digit rune
+		switch {
+		case l.isAtEnd():
+			return 0, false
+		case '0' <= l.currentChar && l.currentChar <= '9':
+			digit = rune(l.currentChar - '0')
+		case 'a' <= l.currentChar && l.currentChar <= 'f':
+			digit = rune(l.currentChar-'a') + 10
+		case 'A' <= l.currentChar && l.currentChar <= 'F':
+			digit = rune(l.currentChar-'A') + 10
+		default:
+			l.addError(CodeInvalidEscape, fmt.Sprintf("Invalid hex digit %q in unicode escape sequence.", l.currentChar), start, l.afterCurrentChar())
+
+			return 0, false
+		}
+
+		codePoint = codePoint*16 + digit
 		l.readChar()
 	}
-	endPosition := l.position
 
-	return l.input[startPosition-1 : endPosition-1]
+	return codePoint, true
 }
 
 func (l *Lexer) eatWhitespace() {
 	character := l.currentChar
 	whitespaceChars := []byte{' ', '\t', '\n', '\r', '\v', '\f'}
+	if l.strictWhitespace {
+		whitespaceChars = whitespaceChars[:4]
+	}
 
 	for slices.Contains(whitespaceChars, character) {
 		if l.currentChar == '\n' {
@@ -67,26 +559,104 @@ func (l *Lexer) eatWhitespace() {
 	}
 }
 
+// maxKeywordLiteral limits the literal kept of a run of letters. It is long
+// enough to show unquoted keys in error messages, while a huge run of letters
+// does not take up memory.
+const maxKeywordLiteral = 255
+
+// readKeyword reads a run of letters, keeping at most one character more
+// than maxKeywordLiteral of it.
 func (l *Lexer) readKeyword() string {
-	startPos := l.position
+	l.startLiteral(maxKeywordLiteral)
 	for l.isCharLetter() {
 		l.readChar()
 	}
-	endPos := l.position
 
-	return l.input[startPos-1 : endPos-1]
+	return l.endLiteral()
+}
+
+// readNumber reads a number following the RFC 8259 grammar, which is
+// [ minus ] int [ frac ] [ exp ]. A malformed number gets reported and read
+// till its end, so that it ends up in a single token.
+func (l *Lexer) readNumber() (string, bool) {
+	start := *l.context
+	l.startLiteral(l.limits.MaxNumberLength)
+
+	if l.currentChar == '-' {
+		l.readChar()
+	}
+
+	switch {
+	case l.currentChar == '0':
+		zeroPosition := *l.context
+		l.readChar()
+		if l.isCharDigit() {
+			return l.readMalformedNumber("Leading zeros are not allowed in numbers.", zeroPosition)
+		}
+	case l.isCharDigit():
+		l.readDigits()
+	default:
+		return l.readMalformedNumber("Expected a digit after the minus sign.", *l.context)
+	}
+
+	if l.currentChar == '.' {
+		l.readChar()
+		if l.isCharDigit() == false {
+			return l.readMalformedNumber("Expected a digit after the decimal point.", *l.context)
+		}
+		l.readDigits()
+	}
+
+	if l.currentChar == 'e' || l.currentChar == 'E' {
+		l.readChar()
+		if l.currentChar == '+' || l.currentChar == '-' {
+			l.readChar()
+		}
+		if l.isCharDigit() == false {
+			return l.readMalformedNumber("Expected a digit in the exponent.", *l.context)
+		}
+		l.readDigits()
+	}
+
+	if l.isNumberChar() {
+		return l.readMalformedNumber(fmt.Sprintf("Unexpected character '%c' in number.", l.currentChar), *l.context)
+	}
+
+	number := l.endLiteral()
+	if l.limits.MaxNumberLength > 0 && len(number) > l.limits.MaxNumberLength {
+		l.addError(CodeNumberTooLong, fmt.Sprintf("Number is longer than the limit of %d characters.", l.limits.MaxNumberLength), start, *l.context)
+
+		return number, false
+	}
+
+	return number, true
 }
 
-func (l *Lexer) readNumber() string {
-	startPos := l.position
-	// NOTE: this will also read and tokenize faulty 'numbers', like: 1.1.1
-    // However, those faulty numbers will get caught by the parser.
-	for l.isCharDigit() || l.currentChar == '.' {
+func (l *Lexer) readMalformedNumber(message string, position ParseContext) (string, bool) {
+	for l.isNumberChar() {
 		l.readChar()
 	}
-	endPos := l.position
 
-	return l.input[startPos-1 : endPos-1]
+	end := *l.context
+	if end.Offset <= position.Offset {
+		// the error points at the character following the number
+		end = l.afterCurrentChar()
+	}
+	l.addError(CodeInvalidNumber, message, position, end)
+
+	return l.endLiteral(), false
+}
+
+func (l *Lexer) readDigits() {
+	for l.isCharDigit() {
+		l.readChar()
+	}
+}
+
+// isNumberChar reports whether the current character could be a part of
+// a number, valid or not.
+func (l *Lexer) isNumberChar() bool {
+	return l.isCharDigit() || l.isCharLetter() || slices.Contains([]byte{'.', '+', '-'}, l.currentChar)
 }
 
 func (l *Lexer) isCharLetter() bool {
@@ -104,46 +674,100 @@ func (l *Lexer) ReadToken() token.Token {
 
 	l.eatWhitespace()
 
+	if l.isAtEnd() {
+		newToken = *token.New(token.EoF, "", l.context.Line, l.context.Column, l.context.Offset)
+		l.setEnd(&newToken)
+
+		return newToken
+	}
+
 	switch l.currentChar {
 	case ',':
-		newToken = *token.New(token.COMMA, string(l.currentChar), l.context.Line, l.context.Column)
+		newToken = *token.New(token.COMMA, string(l.currentChar), l.context.Line, l.context.Column, l.context.Offset)
 	case ':':
-		newToken = *token.New(token.COLON, string(l.currentChar), l.context.Line, l.context.Column)
-	case '-':
-		newToken = *token.New(token.MINUS, string(l.currentChar), l.context.Line, l.context.Column)
+		newToken = *token.New(token.COLON, string(l.currentChar), l.context.Line, l.context.Column, l.context.Offset)
 	case '{':
-		newToken = *token.New(token.LBRACE, string(l.currentChar), l.context.Line, l.context.Column)
+		newToken = *token.New(token.LBRACE, string(l.currentChar), l.context.Line, l.context.Column, l.context.Offset)
 	case '}':
-		newToken = *token.New(token.RBRACE, string(l.currentChar), l.context.Line, l.context.Column)
+		newToken = *token.New(token.RBRACE, string(l.currentChar), l.context.Line, l.context.Column, l.context.Offset)
 	case '[':
-		newToken = *token.New(token.LSQUARE_BRACE, string(l.currentChar), l.context.Line, l.context.Column)
+		newToken = *token.New(token.LSQUARE_BRACE, string(l.currentChar), l.context.Line, l.context.Column, l.context.Offset)
 	case ']':
-		newToken = *token.New(token.RSQUARE_BRACE, string(l.currentChar), l.context.Line, l.context.Column)
+		newToken = *token.New(token.RSQUARE_BRACE, string(l.currentChar), l.context.Line, l.context.Column, l.context.Offset)
 	case '"':
+		// NOTE: the column of a string points at its first character, not at the opening quote
+		beginningColumn, beginningOffset := l.context.Column+1, l.context.Offset+1
 		jsonString := l.readJsonString()
-		beginningColumn := l.context.Column - len(jsonString)
-		newToken = *token.New(token.STRING, jsonString, l.context.Line, beginningColumn)
-	case 0:
-		newToken = *token.New(token.EoF, "", l.context.Line, l.context.Column)
+		newToken = *token.New(token.STRING, jsonString, l.context.Line, beginningColumn, beginningOffset)
+		// the closing quote is not a part of the span
+		l.setEnd(&newToken)
 	default:
 		if l.isCharLetter() {
+			beginningColumn, beginningOffset := l.context.Column, l.context.Offset
 			keyword := l.readKeyword()
-			beginningColumn := l.context.Column - len(keyword)
-			newToken = *token.New(token.LookupKeyword(keyword), keyword, l.context.Line, beginningColumn)
+			newToken = *token.New(token.LookupKeyword(keyword), keyword, l.context.Line, beginningColumn, beginningOffset)
+			l.setEnd(&newToken)
 
 			return newToken
-		} else if l.isCharDigit() {
-			digit := l.readNumber()
-			beginningColumn := l.context.Column - len(digit)
-			newToken = *token.New(token.NUMBER, digit, l.context.Line, beginningColumn)
+		} else if l.isCharDigit() || l.currentChar == '-' {
+			beginningColumn, beginningOffset := l.context.Column, l.context.Offset
+			number, ok := l.readNumber()
+
+			var tokenType token.TokenType = token.NUMBER
+			if ok == false {
+				tokenType = token.INVALID
+			}
+			newToken = *token.New(tokenType, number, l.context.Line, beginningColumn, beginningOffset)
+			l.setEnd(&newToken)
 
 			return newToken
+		} else if l.currentChar >= utf8.RuneSelf {
+			return l.readNonASCIIToken()
 		}
 
-		newToken = *token.New(token.INVALID, string(l.currentChar), l.context.Line, l.context.Column)
+		newToken = *token.New(token.INVALID, string(l.currentChar), l.context.Line, l.context.Column, l.context.Offset)
 	}
 
 	l.readChar()
+	if newToken.Type != token.STRING {
+		l.setEnd(&newToken)
+	}
+
+	return newToken
+}
+
+// readNonASCIIToken reads a character encoded with more than one byte outside
+// of a string. A byte order mark at the start of the input is skipped, any
+// other character ends up in an INVALID token.
+func (l *Lexer) readNonASCIIToken() token.Token {
+	beginning := *l.context
+	sequence, ok := l.readUTF8Sequence()
+
+	if sequence == byteOrderMark && beginning.Offset == 0 {
+		if l.rejectBOM {
+			l.addError(CodeUnexpectedBOM, "Byte order mark is not allowed at the start of the input.", beginning, *l.context)
+		}
+		// the byte order mark takes no columns
+		l.context.Column = beginning.Column
+
+		return l.ReadToken()
+	}
+
+	if ok == false && l.replaceInvalidUTF8 {
+		sequence = string(utf8.RuneError)
+	} else if ok == false {
+		l.addError(CodeInvalidUTF8, fmt.Sprintf("Invalid UTF-8 byte sequence %q.", sequence), beginning, *l.context)
+	}
+
+	newToken := *token.New(token.INVALID, sequence, beginning.Line, beginning.Column, beginning.Offset)
+	l.setEnd(&newToken)
 
 	return newToken
 }
+
+// setEnd makes the token end right before the current character.
+func (l *Lexer) setEnd(newToken *token.Token) {
+	newToken.EndLine = l.context.Line
+	newToken.EndColumn = l.context.Column
+	newToken.EndOffset = l.context.Offset
+}
@@ -1,7 +1,12 @@
 package lexer
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"sw/json-parser/token"
 )
@@ -195,8 +200,7 @@ func TestLexerTokenizesNumbers(t *testing.T) {
 		{token.COMMA, ",", 1, 45},
 		{token.STRING, "cars", 1, 48},
 		{token.COLON, ":", 1, 53},
-		{token.MINUS, "-", 1, 55},
-		{token.NUMBER, "1", 1, 56},
+		{token.NUMBER, "-1", 1, 55},
 		{token.RBRACE, "}", 1, 57},
 	}
 
@@ -268,3 +272,472 @@ func TestLexerContextTracksTokenPosition(t *testing.T) {
 		}
 	}
 }
+
+func TestLexerDecodesStringEscapes(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+	}{
+		{`"say \"hi\""`, `say "hi"`},
+		{`"C:\\Users\\joe"`, `C:\Users\joe`},
+		{`"a\/b"`, "a/b"},
+		{`"\b\f\n\r\t"`, "\b\f\n\r\t"},
+		{`"\u0041\u00e9\u20AC"`, "Aé€"},
+		{`"\ud83d\ude00"`, "😀"},
+		{`"zażółć"`, "zażółć"},
+	}
+
+	for i, test := range tests {
+		lexer := New(test.input)
+		tok := lexer.ReadToken()
+
+		if tok.Literal != test.expectedLiteral {
+			t.Fatalf("tests[%d] - literal is wrong. Expected=%q, but got=%q", i, test.expectedLiteral, tok.Literal)
+		}
+
+		if errors := lexer.TakeErrors(); len(errors) != 0 {
+			t.Fatalf("tests[%d] - unexpected errors: %v", i, errors)
+		}
+	}
+}
+
+func TestLexerStringColumnsWithEscapes(t *testing.T) {
+	input := `["a\"b", "c"]`
+
+	lexer := New(input)
+	lexer.ReadToken()
+
+	first := lexer.ReadToken()
+	if first.Column != 3 {
+		t.Fatalf("column is wrong. Expected=%d, but got=%d", 3, first.Column)
+	}
+
+	lexer.ReadToken()
+	second := lexer.ReadToken()
+	if second.Literal != "c" || second.Column != 11 {
+		t.Fatalf("token is wrong. Expected=%q at %d, but got=%q at %d", "c", 11, second.Literal, second.Column)
+	}
+}
+
+func TestLexerReportsMalformedEscapes(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedLine   int
+		expectedColumn int
+	}{
+		{`"\x"`, 1, 2},
+		{`"ab\u12G4"`, 1, 4},
+		{`"\u12"`, 1, 2},
+		{`"\udc00"`, 1, 2},
+		{`"\ud800"`, 1, 2},
+		{`"\ud800\n"`, 1, 2},
+		{`"\ud800\u0041"`, 1, 2},
+		{"\"tab\there\"", 1, 5},
+		{"\n  \"line\nbreak\"", 2, 8},
+	}
+
+	for i, test := range tests {
+		lexer := New(test.input)
+		tok := lexer.ReadToken()
+
+		if tok.Type != token.STRING {
+			t.Fatalf("tests[%d] - tokentype is wrong. Expected=%q, but got=%q", i, token.STRING, tok.Type)
+		}
+
+		errors := lexer.TakeErrors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected exactly one error, but got %v", i, errors)
+		}
+
+		if errors[0].Line != test.expectedLine || errors[0].Column != test.expectedColumn {
+			t.Fatalf("tests[%d] - error position is wrong. Expected=%d:%d, but got=%d:%d", i, test.expectedLine, test.expectedColumn, errors[0].Line, errors[0].Column)
+		}
+	}
+}
+
+func TestLexerReportsUnterminatedStrings(t *testing.T) {
+	tests := []string{`"abc`, `"abc\`, `"abc\u00`, `"\ud83d`}
+
+	for i, input := range tests {
+		lexer := New(input)
+		lexer.ReadToken()
+
+		errors := lexer.TakeErrors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected exactly one error, but got %v", i, errors)
+		}
+
+		expectedMessage := "Unterminated string starting at line 1 column 1."
+		if errors[0].Message != expectedMessage {
+			t.Fatalf("tests[%d] - message is wrong. Expected=%q, but got=%q", i, expectedMessage, errors[0].Message)
+		}
+
+		if tok := lexer.ReadToken(); tok.Type != token.EoF {
+			t.Fatalf("tests[%d] - tokentype is wrong. Expected=%q, but got=%q", i, token.EoF, tok.Type)
+		}
+	}
+}
+
+func TestLexerDoesNotTreatNulByteAsEndOfInput(t *testing.T) {
+	lexer := New("[\x00]")
+	lexer.ReadToken()
+
+	if tok := lexer.ReadToken(); tok.Type != token.INVALID {
+		t.Fatalf("tokentype is wrong. Expected=%q, but got=%q", token.INVALID, tok.Type)
+	}
+}
+
+func TestLexerTokenizesNumberGrammar(t *testing.T) {
+	tests := []string{"0", "-0", "7", "-88", "123.123", "1e10", "2.5E-3", "-0.0e+1", "10E5", "0.5"}
+
+	for i, input := range tests {
+		lexer := New(input)
+		tok := lexer.ReadToken()
+
+		if tok.Type != token.NUMBER {
+			t.Fatalf("tests[%d] - tokentype is wrong. Expected=%q, but got=%q", i, token.NUMBER, tok.Type)
+		}
+
+		if tok.Literal != input {
+			t.Fatalf("tests[%d] - literal is wrong. Expected=%q, but got=%q", i, input, tok.Literal)
+		}
+
+		if errors := lexer.TakeErrors(); len(errors) != 0 {
+			t.Fatalf("tests[%d] - unexpected errors: %v", i, errors)
+		}
+	}
+}
+
+func TestLexerReportsMalformedNumbers(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+		expectedMessage string
+		expectedColumn  int
+	}{
+		{"007", "007", "Leading zeros are not allowed in numbers.", 1},
+		{"-01", "-01", "Leading zeros are not allowed in numbers.", 2},
+		{"1.", "1.", "Expected a digit after the decimal point.", 3},
+		{"1.e5", "1.e5", "Expected a digit after the decimal point.", 3},
+		{"-", "-", "Expected a digit after the minus sign.", 2},
+		{"-a", "-a", "Expected a digit after the minus sign.", 2},
+		{"1e", "1e", "Expected a digit in the exponent.", 3},
+		{"1E+", "1E+", "Expected a digit in the exponent.", 4},
+		{"88.88.88", "88.88.88", "Unexpected character '.' in number.", 6},
+		{"12abc", "12abc", "Unexpected character 'a' in number.", 3},
+	}
+
+	for i, test := range tests {
+		lexer := New(test.input + ",")
+		tok := lexer.ReadToken()
+
+		if tok.Type != token.INVALID {
+			t.Fatalf("tests[%d] - tokentype is wrong. Expected=%q, but got=%q", i, token.INVALID, tok.Type)
+		}
+
+		if tok.Literal != test.expectedLiteral {
+			t.Fatalf("tests[%d] - literal is wrong. Expected=%q, but got=%q", i, test.expectedLiteral, tok.Literal)
+		}
+
+		errors := lexer.TakeErrors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected exactly one error, but got %v", i, errors)
+		}
+
+		if errors[0].Message != test.expectedMessage {
+			t.Fatalf("tests[%d] - message is wrong. Expected=%q, but got=%q", i, test.expectedMessage, errors[0].Message)
+		}
+
+		if errors[0].Column != test.expectedColumn {
+			t.Fatalf("tests[%d] - column is wrong. Expected=%d, but got=%d", i, test.expectedColumn, errors[0].Column)
+		}
+
+		if tok := lexer.ReadToken(); tok.Type != token.COMMA {
+			t.Fatalf("tests[%d] - malformed number was not read till its end, got %q", i, tok.Literal)
+		}
+	}
+}
+
+func TestReaderLexerMatchesStringLexer(t *testing.T) {
+	input := "{\n  \"name\": \"Jo\\u00e9\",\n  \"values\": [1.5e3, -0, true, null],\n  \"bad\": 007\n}"
+
+	stringLexer := New(input)
+	readerLexer := NewReader(iotest.OneByteReader(strings.NewReader(input)))
+
+	for i := 0; ; i++ {
+		expected := stringLexer.ReadToken()
+		tok := readerLexer.ReadToken()
+
+		if tok != expected {
+			t.Fatalf("tests[%d] - token is wrong. Expected=%+v, but got=%+v", i, expected, tok)
+		}
+
+		if len(stringLexer.TakeErrors()) != len(readerLexer.TakeErrors()) {
+			t.Fatalf("tests[%d] - lexers reported different errors", i)
+		}
+
+		if tok.Type == token.EoF {
+			break
+		}
+	}
+}
+
+func TestReaderLexerReadsLongInputInChunks(t *testing.T) {
+	const elements = 100000
+	reader := io.MultiReader(strings.NewReader("["), strings.NewReader(strings.Repeat(`"value", `, elements)), strings.NewReader("1]"))
+
+	lexer := NewReader(reader)
+
+	stringCount, last := 0, token.Token{}
+	for tok := lexer.ReadToken(); tok.Type != token.EoF; tok = lexer.ReadToken() {
+		if tok.Type == token.STRING {
+			stringCount++
+		}
+		last = tok
+	}
+
+	if stringCount != elements {
+		t.Fatalf("Unexpected amount of strings. Expected=%d, but got=%d", elements, stringCount)
+	}
+
+	if last.Type != token.RSQUARE_BRACE || last.Offset != elements*9+2 || last.Column != elements*9+3 {
+		t.Fatalf("Unexpected last token %+v", last)
+	}
+}
+
+func TestReaderLexerReportsReadErrors(t *testing.T) {
+	reader := io.MultiReader(strings.NewReader(`["abc`), iotest.ErrReader(errors.New("connection reset")))
+
+	lexer := NewReader(reader)
+	lexer.ReadToken()
+	lexer.ReadToken()
+
+	lexerErrors := lexer.TakeErrors()
+	if len(lexerErrors) != 2 || lexerErrors[0].Code != CodeReadError || lexerErrors[1].Code != CodeUnterminatedString {
+		t.Fatalf("Unexpected errors %+v", lexerErrors)
+	}
+
+	if lexerErrors[0].Message != "Reading the input failed: connection reset." {
+		t.Fatalf("Unexpected message %q", lexerErrors[0].Message)
+	}
+}
+
+func TestLexerStartsAtGivenPosition(t *testing.T) {
+	l := NewAt(`{"id": 7}`, ParseContext{Line: 12, Column: 4, Offset: 300})
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+		expectedOffset  int
+	}{
+		{"{", 12, 4, 300},
+		{"id", 12, 6, 302},
+		{":", 12, 9, 305},
+		{"7", 12, 11, 307},
+		{"}", 12, 12, 308},
+	}
+
+	for i, test := range tests {
+		tok := l.ReadToken()
+		if tok.Literal != test.expectedLiteral || tok.Line != test.expectedLine || tok.Column != test.expectedColumn || tok.Offset != test.expectedOffset {
+			t.Fatalf("tests[%d] - token is wrong. Expected=%q at %d:%d (offset %d), but got=%q at %d:%d (offset %d)", i, test.expectedLiteral, test.expectedLine, test.expectedColumn, test.expectedOffset, tok.Literal, tok.Line, tok.Column, tok.Offset)
+		}
+	}
+}
+
+func TestLexerLimits(t *testing.T) {
+	tests := []struct {
+		input           string
+		limits          Limits
+		expectedCode    string
+		expectedColumn  int
+		expectedLiteral string
+	}{
+		{`["abc", 12345, false]`, Limits{MaxInputSize: 21, MaxStringLength: 3, MaxNumberLength: 5}, "", 0, ""},
+		{`["abc", 12345, false]`, Limits{MaxInputSize: 20}, CodeInputTooLarge, 21, "false"},
+		{`["abcd"]`, Limits{MaxStringLength: 3}, CodeStringTooLong, 2, "abc"},
+		{`["abé"]`, Limits{MaxStringLength: 3}, CodeStringTooLong, 2, "ab\xc3"},
+		{`["` + strings.Repeat("x", 100000) + `", 1]`, Limits{MaxStringLength: 10}, CodeStringTooLong, 2, "xxxxxxxxxx"},
+		{`[-1.5e10]`, Limits{MaxNumberLength: 6}, CodeNumberTooLong, 2, "-1.5e10"},
+		{`[` + strings.Repeat("9", 100000) + `]`, Limits{MaxNumberLength: 20}, CodeNumberTooLong, 2, strings.Repeat("9", 21)},
+		{`[true, false, null]`, Limits{MaxNumberLength: 1}, "", 0, ""},
+	}
+
+	for i, test := range tests {
+		l := New(test.input)
+		l.SetLimits(test.limits)
+
+		var lexerErrors []LexerError
+		var literal string
+		for tok := l.ReadToken(); tok.Type != token.EoF; tok = l.ReadToken() {
+			if tokenErrors := l.TakeErrors(); len(tokenErrors) > 0 && lexerErrors == nil {
+				lexerErrors = tokenErrors
+				literal = tok.Literal
+			}
+		}
+		lexerErrors = append(lexerErrors, l.TakeErrors()...)
+
+		if test.expectedCode == "" {
+			if len(lexerErrors) != 0 {
+				t.Fatalf("tests[%d] - lexer reported errors. Errors: %v", i, lexerErrors)
+			}
+			continue
+		}
+
+		if len(lexerErrors) != 1 || lexerErrors[0].Code != test.expectedCode || lexerErrors[0].Column != test.expectedColumn {
+			t.Fatalf("tests[%d] - expected %s at column %d, but got=%v", i, test.expectedCode, test.expectedColumn, lexerErrors)
+		}
+
+		if literal != test.expectedLiteral {
+			t.Fatalf("tests[%d] - literal is wrong. Expected=%q, but got=%q", i, test.expectedLiteral, literal)
+		}
+	}
+
+	// a run of letters is read through without keeping all of it
+	l := New(`[` + strings.Repeat("x", 100000) + `]`)
+	l.ReadToken()
+	if tok := l.ReadToken(); tok.Type != token.INVALID || tok.Literal != strings.Repeat("x", maxKeywordLiteral+1) || tok.EndOffset != 100001 {
+		t.Fatalf("Unexpected token %q of type %s ending at %d", tok.Literal, tok.Type, tok.EndOffset)
+	}
+
+	if tok := l.ReadToken(); tok.Type != token.RSQUARE_BRACE {
+		t.Fatalf("Expected ']' after the letters, but got=%q", tok.Literal)
+	}
+}
+
+func TestLexerTokenSpans(t *testing.T) {
+	input := "{\"name\": \"Zoë 😀\", \"n\": -1.5}\n[true]"
+
+	tests := []struct {
+		unit     ColumnUnit
+		expected []string
+	}{
+		{ColumnBytes, []string{"{ 1:1-1:2 0-1", "name 1:3-1:7 2-6", ": 1:8-1:9 7-8", "Zoë 😀 1:11-1:20 10-19", ", 1:21-1:22 20-21", "n 1:24-1:25 23-24", ": 1:26-1:27 25-26", "-1.5 1:28-1:32 27-31", "} 1:32-1:33 31-32", "[ 2:1-2:2 33-34", "true 2:2-2:6 34-38", "] 2:6-2:7 38-39", " 2:7-2:7 39-39"}},
+		{ColumnCodePoints, []string{"{ 1:1-1:2 0-1", "name 1:3-1:7 2-6", ": 1:8-1:9 7-8", "Zoë 😀 1:11-1:16 10-19", ", 1:17-1:18 20-21", "n 1:20-1:21 23-24", ": 1:22-1:23 25-26", "-1.5 1:24-1:28 27-31", "} 1:28-1:29 31-32", "[ 2:1-2:2 33-34", "true 2:2-2:6 34-38", "] 2:6-2:7 38-39", " 2:7-2:7 39-39"}},
+		{ColumnUTF16, []string{"{ 1:1-1:2 0-1", "name 1:3-1:7 2-6", ": 1:8-1:9 7-8", "Zoë 😀 1:11-1:17 10-19", ", 1:18-1:19 20-21", "n 1:21-1:22 23-24", ": 1:23-1:24 25-26", "-1.5 1:25-1:29 27-31", "} 1:29-1:30 31-32", "[ 2:1-2:2 33-34", "true 2:2-2:6 34-38", "] 2:6-2:7 38-39", " 2:7-2:7 39-39"}},
+	}
+
+	for i, test := range tests {
+		l := New(input)
+		l.SetColumnUnit(test.unit)
+
+		for idx, expected := range test.expected {
+			tok := l.ReadToken()
+			span := fmt.Sprintf("%s %d:%d-%d:%d %d-%d", tok.Literal, tok.Line, tok.Column, tok.EndLine, tok.EndColumn, tok.Offset, tok.EndOffset)
+			if span != expected {
+				t.Fatalf("tests[%d] - span of token %d is wrong. Expected=%q, but got=%q", i, idx, expected, span)
+			}
+		}
+	}
+
+	end := ColumnUTF16.Advance(ParseContext{Line: 3, Column: 5, Offset: 10}, "é😀\nab")
+	if end != (ParseContext{Line: 4, Column: 3, Offset: 19}) {
+		t.Fatalf("Advanced position is wrong. Expected=4:3 (offset 19), but got=%+v", end)
+	}
+}
+
+func TestLexerErrorSpans(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`["a\qb"]`, "invalid_escape 1:4-1:6 3-5"},
+		{`["\uD800x"]`, "invalid_surrogate 1:3-1:9 2-8"},
+		{`["\u12G4"]`, "invalid_escape 1:3-1:8 2-7"},
+		{"[\"é\x01\"]", "control_character 1:5-1:6 4-5"},
+		{`[0123]`, "invalid_number 1:2-1:6 1-5"},
+		{`[1.]`, "invalid_number 1:4-1:5 3-4"},
+		{`["abc`, "unterminated_string 1:2-1:6 1-5"},
+	}
+
+	for i, test := range tests {
+		l := New(test.input)
+
+		var lexerErrors []LexerError
+		for tok := l.ReadToken(); tok.Type != token.EoF && len(lexerErrors) == 0; tok = l.ReadToken() {
+			lexerErrors = l.TakeErrors()
+		}
+
+		if len(lexerErrors) == 0 {
+			t.Fatalf("tests[%d] - expected an error for %q", i, test.input)
+		}
+
+		lexerError := lexerErrors[0]
+		span := fmt.Sprintf("%s %d:%d-%d:%d %d-%d", lexerError.Code, lexerError.Line, lexerError.Column, lexerError.EndLine, lexerError.EndColumn, lexerError.Offset, lexerError.EndOffset)
+		if span != test.expected {
+			t.Fatalf("tests[%d] - error span is wrong. Expected=%q, but got=%q", i, test.expected, span)
+		}
+	}
+}
+
+func TestLexerValidatesUTF8(t *testing.T) {
+	tests := []struct {
+		input           string
+		replace         bool
+		expectedLiteral string
+		expectedError   string
+	}{
+		{"\"a\xffb\"", false, "a\uFFFDb", "invalid_utf8 1:3-1:4 2-3"},
+		{"\"a\xffb\"", true, "a\uFFFDb", ""},
+		{"\"\xe2\x82\"", false, "\uFFFD", "invalid_utf8 1:2-1:4 1-3"},
+		{"\"\xed\xa0\x80\"", false, "\uFFFD", "invalid_utf8 1:2-1:5 1-4"},
+		{"\"\xc0\xafx\"", true, "\uFFFDx", ""},
+		{"\"é😀\"", false, "é😀", ""},
+		{"\xff", false, "\xff", "invalid_utf8 1:1-1:2 0-1"},
+		{"\xff", true, "\uFFFD", ""},
+		{"é", false, "é", ""},
+		{" \xef\xbb\xbf", false, "\uFEFF", ""},
+	}
+
+	for i, test := range tests {
+		l := New(test.input)
+		l.SetReplaceInvalidUTF8(test.replace)
+
+		tok := l.ReadToken()
+		if tok.Literal != test.expectedLiteral {
+			t.Fatalf("tests[%d] - literal is wrong. Expected=%q, but got=%q", i, test.expectedLiteral, tok.Literal)
+		}
+
+		span := ""
+		if lexerErrors := l.TakeErrors(); len(lexerErrors) > 0 {
+			lexerError := lexerErrors[0]
+			span = fmt.Sprintf("%s %d:%d-%d:%d %d-%d", lexerError.Code, lexerError.Line, lexerError.Column, lexerError.EndLine, lexerError.EndColumn, lexerError.Offset, lexerError.EndOffset)
+		}
+
+		if span != test.expectedError {
+			t.Fatalf("tests[%d] - error is wrong. Expected=%q, but got=%q", i, test.expectedError, span)
+		}
+	}
+
+	// an invalid sequence takes a single column
+	l := New("[\"\xf0\", 1]")
+	l.SetColumnUnit(ColumnUTF16)
+	l.SetReplaceInvalidUTF8(true)
+	l.ReadToken()
+	l.ReadToken()
+	if comma := l.ReadToken(); comma.Type != token.COMMA || comma.Column != 5 {
+		t.Fatalf("Comma is at the wrong position. Expected=5, but got=%d", comma.Column)
+	}
+}
+
+func TestLexerSkipsByteOrderMark(t *testing.T) {
+	for _, reject := range []bool{false, true} {
+		l := New("\xef\xbb\xbf{}")
+		l.SetRejectBOM(reject)
+
+		tok := l.ReadToken()
+		if tok.Type != token.LBRACE || tok.Column != 1 || tok.Offset != 3 {
+			t.Fatalf("First token is wrong. Expected '{' at column 1 (offset 3), but got=%q at column %d (offset %d)", tok.Literal, tok.Column, tok.Offset)
+		}
+
+		lexerErrors := l.TakeErrors()
+		if reject && (len(lexerErrors) != 1 || lexerErrors[0].Code != CodeUnexpectedBOM || lexerErrors[0].Offset != 0 || lexerErrors[0].EndOffset != 3) {
+			t.Fatalf("Expected an unexpected_bom error, but got=%v", lexerErrors)
+		}
+
+		if reject == false && len(lexerErrors) != 0 {
+			t.Fatalf("Byte order mark was reported. Errors: %v", lexerErrors)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// BigNumberMode decides how numbers are parsed that cannot be represented
+// exactly by an int.
+type BigNumberMode int
+
+const (
+	// BigNumbersOff parses them into a float64, which may round them.
+	BigNumbersOff BigNumberMode = iota
+	// BigNumbersRat parses integers that do not fit into an int into a
+	// *big.Int and numbers with a fraction or an exponent into an exact
+	// *big.Rat.
+	BigNumbersRat
+	// BigNumbersFloat works like BigNumbersRat, but uses a *big.Float with
+	// the precision of ParserOptions.BigFloatPrecision.
+	BigNumbersFloat
+)
+
+// maxExactExponent limits the exponent of numbers converted into a *big.Rat
+// in both directions, since an input like 1e999999999 or 1e-999999999 would
+// take gigabytes to represent exactly.
+const maxExactExponent = 10000
+
+func (parser *Parser) parseBigNumber(literal string) any {
+	if integer, ok := new(big.Int).SetString(literal, 10); ok {
+		return integer
+	}
+
+	if parser.options.BigNumbers == BigNumbersFloat {
+		precision := parser.options.BigFloatPrecision
+		if precision == 0 {
+			precision = literalPrecision(literal)
+		}
+
+		float, _, err := big.ParseFloat(literal, 10, precision, big.ToNearestEven)
+		if err == nil {
+			return float
+		}
+
+		parser.errorHandler.AddTokenError(CodeInvalidNumber, "The exponent of the number is out of the range of a *big.Float.", &parser.currentToken)
+
+		return nil
+	}
+
+	if exponentOutOfRange(literal) {
+		parser.errorHandler.AddTokenError(CodeInvalidNumber, fmt.Sprintf("The exponent of the number is outside of ±%d, which is the range of exact values.", maxExactExponent), &parser.currentToken)
+
+		return nil
+	}
+
+	if rat, ok := new(big.Rat).SetString(literal); ok {
+		return rat
+	}
+
+	parser.errorHandler.AddTokenError(CodeInvalidNumber, "It was not possible to parse the token literal as a number.", &parser.currentToken)
+
+	return nil
+}
+
+// literalPrecision returns a precision in bits big enough to hold all of the
+// digits of the number literal.
+func literalPrecision(literal string) uint {
+	precision := uint(len(literal)) * 4
+	if precision < 64 {
+		precision = 64
+	}
+
+	return precision
+}
+
+func exponentOutOfRange(literal string) bool {
+	idx := strings.IndexAny(literal, "eE")
+	if idx < 0 {
+		return false
+	}
+
+	exponent, err := strconv.Atoi(literal[idx+1:])
+
+	return err != nil || exponent > maxExactExponent || exponent < -maxExactExponent
+}
+
+// NumberToRat converts any number produced by the parser, an int, float64,
+// Number, *big.Int, *big.Rat or *big.Float, into an exact rational.
+func NumberToRat(value any) (*big.Rat, error) {
+	switch number := value.(type) {
+	case int:
+		return new(big.Rat).SetInt64(int64(number)), nil
+	case float64:
+		if math.IsInf(number, 0) || math.IsNaN(number) {
+			return nil, fmt.Errorf("number %g has no exact value", number)
+		}
+
+		return new(big.Rat).SetFloat64(number), nil
+	case Number:
+		if exponentOutOfRange(string(number)) == false {
+			if rat, ok := new(big.Rat).SetString(string(number)); ok {
+				return rat, nil
+			}
+		}
+
+		return nil, fmt.Errorf("number %s cannot be represented exactly", number)
+	case *big.Int:
+		return new(big.Rat).SetInt(number), nil
+	case *big.Rat:
+		return new(big.Rat).Set(number), nil
+	case *big.Float:
+		if number.IsInf() {
+			return nil, fmt.Errorf("number %s has no exact value", number.String())
+		}
+		rat, _ := number.Rat(nil)
+
+		return rat, nil
+	}
+
+	return nil, fmt.Errorf("expected a number, but got %T", value)
+}
+
+// CompareNumbers compares the exact values of two numbers of any of the types
+// supported by NumberToRat, so that 1.10, 1.1 and 11/10 are equal. It returns
+// -1, 0 or 1 like big.Rat.Cmp.
+func CompareNumbers(a any, b any) (int, error) {
+	ratA, err := NumberToRat(a)
+	if err != nil {
+		return 0, err
+	}
+
+	ratB, err := NumberToRat(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return ratA.Cmp(ratB), nil
+}
+
+// FormatNumber writes a number of any of the types supported by NumberToRat
+// as a JSON number without rounding it. A *big.Rat is written with all of its
+// decimal digits, which fails for fractions like 1/3 that have infinitely
+// many of them.
+func FormatNumber(value any) (string, error) {
+	switch number := value.(type) {
+	case int:
+		return strconv.Itoa(number), nil
+	case float64:
+		if math.IsInf(number, 0) || math.IsNaN(number) {
+			return "", fmt.Errorf("number %g cannot be written as JSON", number)
+		}
+
+		return strconv.FormatFloat(number, 'g', -1, 64), nil
+	case Number:
+		return string(number), nil
+	case *big.Int:
+		return number.String(), nil
+	case *big.Rat:
+		if number.IsInt() {
+			return number.Num().String(), nil
+		}
+
+		digits, exact := number.FloatPrec()
+		if exact == false {
+			return "", fmt.Errorf("number %s has no finite decimal representation", number.String())
+		}
+
+		return number.FloatString(digits), nil
+	case *big.Float:
+		if number.IsInf() {
+			return "", fmt.Errorf("number %s cannot be written as JSON", number.String())
+		}
+
+		return number.Text('g', -1), nil
+	}
+
+	return "", fmt.Errorf("expected a number, but got %T", value)
+}
@@ -0,0 +1,343 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+
+	"sw/json-parser/lexer"
+	"sw/json-parser/token"
+)
+
+// EventType describes a structural event read by the Decoder.
+type EventType int
+
+const (
+	BeginObject EventType = iota
+	EndObject
+	BeginArray
+	EndArray
+	Key
+	Value
+)
+
+var eventTypeNames = map[EventType]string{
+	BeginObject: "BeginObject",
+	EndObject:   "EndObject",
+	BeginArray:  "BeginArray",
+	EndArray:    "EndArray",
+	Key:         "Key",
+	Value:       "Value",
+}
+
+func (eventType EventType) String() string {
+	return eventTypeNames[eventType]
+}
+
+// Event is a single step through the input. Depth is the amount of arrays
+// and objects the event is nested in, Begin and End events have the depth
+// of the container itself. Token holds the literal and the position of the
+// token the event was read from.
+type Event struct {
+	Type EventType
+	// Key holds the name of the member for Key events
+	Key string
+	// Value holds the string, number, bool or nil of Value events. Numbers
+	// are an int or a float64, a Number with the UseNumber option, and an
+	// int, *big.Int, *big.Rat or *big.Float with the BigNumbers option
+	Value any
+	Depth int
+	Token token.Token
+}
+
+type decoderState int
+
+const (
+	// a top-level value, an array value after a ',' or a member value
+	stateValue decoderState = iota
+	// a value or ']' right after '[' or after a trailing comma
+	stateArrayStart
+	// ',' or ']' after an array value
+	stateArrayComma
+	// a key or '}' right after '{' or after a trailing comma
+	stateObjectStart
+	// ':' after a key
+	stateObjectColon
+	// ',' or '}' after a member value
+	stateObjectComma
+	// after the top-level value
+	stateEnd
+)
+
+// Decoder reads the input as a stream of events without building maps and
+// slices, which allows extracting a few values from big documents. The
+// grammar is checked as the events are read, the first error stops the
+// decoder.
+type Decoder struct {
+	parser *Parser
+	state  decoderState
+	// the opening tokens of the arrays and objects that are not closed yet,
+	// and the amount of values or members read from each of them
+	containers []token.Token
+	counts     []int
+	// the first occurrence of every key of the open objects, only tracked
+	// with DuplicateKeysError
+	keyTokens []map[string]token.Token
+	// nodes counts the values of the current top-level value
+	nodes int
+	// set when the current token was returned as an event and has to be
+	// consumed before reading the next one
+	advance bool
+	err     ParserErrors
+}
+
+// NewDecoder creates a decoder reading tokens from the given lexer. Options
+// are optional, only the first one passed is used. With AllowTrailingData
+// the decoder reads concatenated top-level values one after another.
+func NewDecoder(lexer *lexer.Lexer, options ...ParserOptions) *Decoder {
+	return &Decoder{parser: New(lexer, options...)}
+}
+
+// Token returns the next event. It returns io.EOF after the last top-level
+// value was read, or the ParserErrors of the first error in the input.
+func (decoder *Decoder) Token() (Event, error) {
+	if decoder.err != nil {
+		return Event{}, decoder.err
+	}
+
+	event, ok := decoder.readEvent()
+	if decoder.parser.errorHandler.HasErrors() {
+		decoder.err = decoder.parser.errorHandler.GetErrors()
+
+		return Event{}, decoder.err
+	}
+
+	if ok == false {
+		return Event{}, io.EOF
+	}
+
+	return event, nil
+}
+
+// Depth returns the amount of arrays and objects opened and not closed yet.
+func (decoder *Decoder) Depth() int {
+	return len(decoder.containers)
+}
+
+func (decoder *Decoder) readEvent() (Event, bool) {
+	parser := decoder.parser
+	if decoder.advance {
+		decoder.advance = false
+		parser.nextToken()
+	}
+
+	for parser.errorHandler.HasErrors() == false {
+		switch decoder.state {
+		case stateEnd:
+			if parser.currentToken.Type == token.EoF {
+				return Event{}, false
+			}
+
+			if parser.options.AllowTrailingData {
+				decoder.nodes = 0
+				decoder.state = stateValue
+				continue
+			}
+
+			if parser.currentTokenReported == false {
+				parser.errorHandler.AddTokenError(CodeTrailingData, "Unexpected data after top-level value.", &parser.currentToken)
+			}
+		case stateValue:
+			return decoder.readValue(), true
+		case stateArrayStart:
+			switch parser.currentToken.Type {
+			case token.RSQUARE_BRACE:
+				return decoder.endContainer(EndArray), true
+			case token.EoF:
+				decoder.reportUnclosed()
+			default:
+				if decoder.countValue(parser.options.Limits.MaxArrayLength, CodeArrayTooLong, "Array has more than the limit of %d values.") {
+					return decoder.readValue(), true
+				}
+			}
+		case stateObjectStart:
+			switch parser.currentToken.Type {
+			case token.RBRACE:
+				return decoder.endContainer(EndObject), true
+			case token.EoF:
+				decoder.reportUnclosed()
+			default:
+				if decoder.countValue(parser.options.Limits.MaxMembers, CodeTooManyMembers, "Object has more than the limit of %d members.") == false {
+					break
+				}
+
+				if parser.currentToken.Type != token.STRING {
+					parser.errorHandler.AddTokenError(CodeInvalidKey, "Key value has to be of type string. Did you add quotation marks around the key value?", &parser.currentToken)
+
+					break
+				}
+
+				if decoder.isDuplicateKey() {
+					break
+				}
+
+				decoder.state = stateObjectColon
+				decoder.advance = true
+
+				return Event{Type: Key, Key: parser.currentToken.Literal, Depth: len(decoder.containers), Token: parser.currentToken}, true
+			}
+		case stateObjectColon:
+			if parser.currentToken.Type != token.COLON {
+				parser.errorHandler.AddTokenError(CodeMissingColon, "Key value has to be followed by a colon, but got "+string(parser.currentToken.Type), &parser.currentToken)
+
+				break
+			}
+
+			// consume ':'
+			parser.nextToken()
+			decoder.state = stateValue
+		case stateArrayComma, stateObjectComma:
+			var closingType token.TokenType = token.RSQUARE_BRACE
+			eventType, startState := EndArray, stateArrayStart
+			if decoder.state == stateObjectComma {
+				closingType, eventType, startState = token.RBRACE, EndObject, stateObjectStart
+			}
+
+			switch parser.currentToken.Type {
+			case closingType:
+				return decoder.endContainer(eventType), true
+			case token.EoF:
+				decoder.reportUnclosed()
+			default:
+				// the closing token right after the ',' is a trailing comma,
+				// which consumeComma rejects in strict mode
+				if parser.consumeComma(closingType) {
+					decoder.state = startState
+				}
+			}
+		}
+	}
+
+	return Event{}, false
+}
+
+func (decoder *Decoder) readValue() Event {
+	parser := decoder.parser
+	event := Event{Type: Value, Depth: len(decoder.containers), Token: parser.currentToken}
+	decoder.advance = true
+
+	decoder.nodes++
+	if parser.exceedsLimit(decoder.nodes, parser.options.Limits.MaxNodes, CodeTooManyNodes, "Input has more than the limit of %d values.") {
+		return event
+	}
+
+	switch parser.currentToken.Type {
+	case token.LBRACE, token.LSQUARE_BRACE:
+		if parser.exceedsMaxDepth(len(decoder.containers) + 1) {
+			return event
+		}
+	}
+
+	switch parser.currentToken.Type {
+	case token.LBRACE:
+		event.Type = BeginObject
+		decoder.containers = append(decoder.containers, parser.currentToken)
+		decoder.counts = append(decoder.counts, 0)
+		decoder.keyTokens = append(decoder.keyTokens, nil)
+		decoder.state = stateObjectStart
+
+		return event
+	case token.LSQUARE_BRACE:
+		event.Type = BeginArray
+		decoder.containers = append(decoder.containers, parser.currentToken)
+		decoder.counts = append(decoder.counts, 0)
+		decoder.keyTokens = append(decoder.keyTokens, nil)
+		decoder.state = stateArrayStart
+
+		return event
+	case token.STRING:
+		event.Value = parser.parseString()
+	case token.NUMBER:
+		event.Value = parser.parseNumber()
+	case token.TRUE:
+		event.Value = true
+	case token.FALSE:
+		event.Value = false
+	case token.NULL:
+		event.Value = nil
+	default:
+		parser.reportInvalidValue()
+	}
+	decoder.afterValue()
+
+	return event
+}
+
+func (decoder *Decoder) endContainer(eventType EventType) Event {
+	decoder.containers = decoder.containers[:len(decoder.containers)-1]
+	decoder.counts = decoder.counts[:len(decoder.counts)-1]
+	decoder.keyTokens = decoder.keyTokens[:len(decoder.keyTokens)-1]
+	decoder.advance = true
+	decoder.afterValue()
+
+	return Event{Type: eventType, Depth: len(decoder.containers), Token: decoder.parser.currentToken}
+}
+
+// countValue counts a value or a member of the innermost container and
+// reports whether it is still within the limit.
+func (decoder *Decoder) countValue(limit int, code ErrorCode, message string) bool {
+	decoder.counts[len(decoder.counts)-1]++
+
+	return decoder.parser.exceedsLimit(decoder.counts[len(decoder.counts)-1], limit, code, message) == false
+}
+
+// isDuplicateKey reports the current key when it already appeared in the
+// innermost object. Only DuplicateKeysError is checked by the decoder, the
+// other policies are about the parsed values.
+func (decoder *Decoder) isDuplicateKey() bool {
+	parser := decoder.parser
+	if parser.options.DuplicateKeys != DuplicateKeysError {
+		return false
+	}
+
+	key := parser.currentToken.Literal
+	keyTokens := decoder.keyTokens[len(decoder.keyTokens)-1]
+	if firstToken, ok := keyTokens[key]; ok {
+		parser.errorHandler.AddTokenError(CodeDuplicateKey, fmt.Sprintf("Duplicate key %q, it was already defined at line %d column %d.", key, firstToken.Line, firstToken.Column), &parser.currentToken)
+
+		return true
+	}
+
+	if keyTokens == nil {
+		keyTokens = make(map[string]token.Token)
+		decoder.keyTokens[len(decoder.keyTokens)-1] = keyTokens
+	}
+	keyTokens[key] = parser.currentToken
+
+	return false
+}
+
+func (decoder *Decoder) afterValue() {
+	switch {
+	case len(decoder.containers) == 0:
+		decoder.state = stateEnd
+	case decoder.containerType() == token.LSQUARE_BRACE:
+		decoder.state = stateArrayComma
+	default:
+		decoder.state = stateObjectComma
+	}
+}
+
+func (decoder *Decoder) containerType() token.TokenType {
+	return decoder.containers[len(decoder.containers)-1].Type
+}
+
+func (decoder *Decoder) reportUnclosed() {
+	openingToken := decoder.containers[len(decoder.containers)-1]
+	if openingToken.Type == token.LSQUARE_BRACE {
+		decoder.parser.errorHandler.AddTokenError(CodeUnclosedArray, fmt.Sprintf("Array opened at line %d column %d was never closed.", openingToken.Line, openingToken.Column), &decoder.parser.currentToken)
+
+		return
+	}
+
+	decoder.parser.errorHandler.AddTokenError(CodeUnclosedObject, fmt.Sprintf("Object opened at line %d column %d was never closed.", openingToken.Line, openingToken.Column), &decoder.parser.currentToken)
+}
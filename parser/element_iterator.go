@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"fmt"
+
+	"sw/json-parser/token"
+)
+
+// ElementIterator parses the values of a top-level array one by one, so that
+// huge arrays can be processed without keeping all of them in memory:
+//
+//	iterator := parser.Elements()
+//	for iterator.Next() {
+//		value, err := iterator.Element()
+//		...
+//	}
+//	if err := iterator.Err(); err != nil {
+//		...
+//	}
+type ElementIterator struct {
+	parser *Parser
+	// SkipInvalid keeps iterating after an element that could not be parsed.
+	// Element returns the errors of such an element, and the iterator moves
+	// on to the next one. Without it, the first invalid element stops the
+	// iteration and its errors are returned by Err.
+	SkipInvalid bool
+
+	openingToken token.Token
+	opened       bool
+	// set when skipping an invalid element stopped at the ',' or ']'
+	skipped       bool
+	done          bool
+	index         int
+	value         any
+	elementErrors ParserErrors
+	err           ParserErrors
+}
+
+// Elements returns an iterator over the values of the top-level array. It
+// has to be called instead of Parse, before any value was parsed.
+func (parser *Parser) Elements() *ElementIterator {
+	parser.started = true
+	if parser.options.TrackPositions {
+		parser.positions = make(map[string]token.Token)
+	}
+
+	return &ElementIterator{parser: parser, index: -1}
+}
+
+// Next parses the next element and reports whether there was one.
+func (iterator *ElementIterator) Next() bool {
+	if iterator.done {
+		return false
+	}
+
+	parser := iterator.parser
+	iterator.value, iterator.elementErrors = nil, nil
+
+	if iterator.opened == false {
+		iterator.opened = true
+
+		if parser.currentToken.Type != token.LSQUARE_BRACE {
+			if parser.currentTokenReported == false {
+				parser.errorHandler.AddTokenError(CodeUnexpectedToken, fmt.Sprintf("Expected the input to be an array, but got '%s' instead.", parser.currentToken.Literal), &parser.currentToken)
+			}
+
+			return iterator.stop()
+		}
+
+		// consume '['
+		iterator.openingToken = parser.currentToken
+		parser.nextToken()
+	} else {
+		// every element gets its own errors, they have to be in place before
+		// the first token of the element becomes the current one
+		parser.errorHandler = &ErrorHandler{}
+		if iterator.skipped == false {
+			// move past the previous element
+			parser.nextToken()
+		}
+		iterator.skipped = false
+
+		if parser.currentToken.Type != token.RSQUARE_BRACE && parser.consumeComma(token.RSQUARE_BRACE) == false {
+			iterator.index++
+
+			return iterator.skipInvalid()
+		}
+	}
+
+	switch parser.currentToken.Type {
+	case token.RSQUARE_BRACE:
+		if parser.errorHandler.HasErrors() == false && parser.options.AllowTrailingData == false {
+			parser.nextToken()
+			if parser.currentToken.Type != token.EoF && parser.currentTokenReported == false {
+				parser.errorHandler.AddTokenError(CodeTrailingData, "Unexpected data after top-level value.", &parser.currentToken)
+			}
+		}
+
+		return iterator.stop()
+	case token.EoF:
+		if parser.errorHandler.HasErrors() == false {
+			parser.errorHandler.AddTokenError(CodeUnclosedArray, fmt.Sprintf("Array opened at line %d column %d was never closed.", iterator.openingToken.Line, iterator.openingToken.Column), &parser.currentToken)
+		}
+
+		return iterator.stop()
+	}
+
+	iterator.index++
+	if parser.exceedsLimit(iterator.index+1, parser.options.Limits.MaxArrayLength, CodeArrayTooLong, "Array has more than the limit of %d values.") {
+		return iterator.stop()
+	}
+
+	parser.nodes = 0
+	parser.pushPathSegment(iterator.index)
+	value := parser.parseJson()
+	parser.popPathSegment()
+
+	if parser.errorHandler.HasErrors() {
+		return iterator.skipInvalid()
+	}
+
+	iterator.value = value
+
+	return true
+}
+
+// Element returns the value parsed by the last call to Next, or the errors
+// that made it invalid when SkipInvalid is set.
+func (iterator *ElementIterator) Element() (any, ParserErrors) {
+	return iterator.value, iterator.elementErrors
+}
+
+// Index returns the index of the current element in the array.
+func (iterator *ElementIterator) Index() int {
+	return iterator.index
+}
+
+// Err returns the errors that stopped the iteration, or nil when the whole
+// array was read.
+func (iterator *ElementIterator) Err() ParserErrors {
+	return iterator.err
+}
+
+func (iterator *ElementIterator) stop() bool {
+	iterator.done = true
+	iterator.err = iterator.parser.errorHandler.GetErrors()
+
+	return false
+}
+
+// skipInvalid moves past the element that could not be parsed, up to the
+// ',' or the ']' of the top-level array, so that iterating can go on.
+// The tokens skipped on the way are not reported. When the input ends
+// before, the next call to Next reports the unclosed array.
+func (iterator *ElementIterator) skipInvalid() bool {
+	parser := iterator.parser
+	if iterator.SkipInvalid == false {
+		return iterator.stop()
+	}
+
+	iterator.elementErrors = parser.errorHandler.GetErrors()
+	parser.errorHandler = &ErrorHandler{}
+	for {
+		switch {
+		case parser.currentToken.Type == token.EoF,
+			parser.depth == 0 && parser.currentToken.Type == token.RSQUARE_BRACE,
+			parser.depth == 1 && parser.currentToken.Type == token.COMMA:
+			iterator.skipped = true
+
+			return true
+		}
+
+		parser.nextToken()
+	}
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	ANSI_RESET = "\033[0m"
+	ANSI_RED   = "\033[31m"
+)
+
+func makeStringRed(message string) string {
+	return fmt.Sprintf("%s%s%s", ANSI_RED, message, ANSI_RESET)
+}
+
+// FormatError renders an error for humans. The "PARSER ERROR:" prefix is only
+// colored red when color is set.
+func FormatError(syntaxError *SyntaxError, color bool) string {
+	prefix := "PARSER ERROR:"
+	if color {
+		prefix = makeStringRed(prefix)
+	}
+
+	errorPosition := fmt.Sprintf("%s line %d and column %d near token literal '%s'.", prefix, syntaxError.Line, syntaxError.Column, syntaxError.Literal)
+
+	return fmt.Sprintf("%s\n%s", errorPosition, syntaxError.Message)
+}
+
+// WriteErrors writes every error formatted by FormatError to the writer. The
+// output is colored only when the writer is a terminal.
+func WriteErrors(writer io.Writer, parserErrors ParserErrors) error {
+	color := isTerminal(writer)
+
+	for _, syntaxError := range parserErrors {
+		if _, err := fmt.Fprintln(writer, FormatError(syntaxError, color)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isTerminal(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	if ok == false {
+		return false
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
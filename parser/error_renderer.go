@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrorRenderer renders errors together with the lines of the source they
+// were found in, underlining the offending token with carets:
+//
+//	PARSER ERROR: Key value has to be of type string. Did you add quotation marks around the key value?
+//	 --> line 2, column 5
+//	  |
+//	1 | {
+//	2 |     name: "Joe"
+//	  |     ^^^^
+//	3 | }
+type ErrorRenderer struct {
+	Source string
+	// ContextLines is the amount of lines shown before and after the line
+	// containing the error.
+	ContextLines int
+	Color        bool
+
+	lines []string
+	// lineOffsets holds the byte offset of the start of every line
+	lineOffsets []int
+}
+
+func NewErrorRenderer(source string) *ErrorRenderer {
+	return &ErrorRenderer{Source: source, ContextLines: 2}
+}
+
+func (renderer *ErrorRenderer) Render(syntaxError *SyntaxError) string {
+	if renderer.lines == nil {
+		renderer.lines = strings.Split(renderer.Source, "\n")
+		renderer.lineOffsets = make([]int, len(renderer.lines))
+		offset := 0
+		for idx, line := range renderer.lines {
+			renderer.lineOffsets[idx] = offset
+			offset += len(line) + 1
+			renderer.lines[idx] = strings.TrimSuffix(line, "\r")
+		}
+	}
+
+	firstLine := max(syntaxError.Line-renderer.ContextLines, 1)
+	lastLine := min(syntaxError.Line+renderer.ContextLines, len(renderer.lines))
+	gutterWidth := len(fmt.Sprint(lastLine))
+	emptyGutter := strings.Repeat(" ", gutterWidth) + " |"
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s %s\n", renderer.colorize("PARSER ERROR:"), syntaxError.Message)
+	fmt.Fprintf(&builder, "%s--> line %d, column %d\n", strings.Repeat(" ", gutterWidth), syntaxError.Line, syntaxError.Column)
+	builder.WriteString(emptyGutter + "\n")
+
+	for lineNumber := firstLine; lineNumber <= lastLine; lineNumber++ {
+		line := renderer.lines[lineNumber-1]
+		fmt.Fprintf(&builder, "%*d | %s\n", gutterWidth, lineNumber, line)
+
+		if lineNumber == syntaxError.Line {
+			fmt.Fprintf(&builder, "%s %s\n", emptyGutter, renderer.underline(line, renderer.lineOffsets[lineNumber-1], syntaxError))
+		}
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// RenderErrors renders every error, separating them with an empty line.
+func (renderer *ErrorRenderer) RenderErrors(parserErrors ParserErrors) string {
+	rendered := make([]string, len(parserErrors))
+	for idx, syntaxError := range parserErrors {
+		rendered[idx] = renderer.Render(syntaxError)
+	}
+
+	return strings.Join(rendered, "\n\n")
+}
+
+// underline returns the carets pointing at the error. The span of the error
+// gets underlined up to the end of the line. Errors whose offset does not
+// point into the line are placed by their column instead, underlining the
+// token literal when it can be found there, otherwise a single character.
+func (renderer *ErrorRenderer) underline(line string, lineOffset int, syntaxError *SyntaxError) string {
+	start := syntaxError.Offset - lineOffset
+	width := 1
+
+	if start >= 0 && start <= len(line) {
+		end := min(syntaxError.EndOffset-lineOffset, len(line))
+		if end > start {
+			width = utf8.RuneCountInString(line[start:end])
+		}
+	} else {
+		start = min(max(syntaxError.Column-1, 0), len(line))
+		if literal := syntaxError.Literal; literal != "" && strings.HasPrefix(line[start:], literal) {
+			width = len([]rune(literal))
+		}
+	}
+
+	// keep tabs, so that the carets line up with the source line
+	var padding strings.Builder
+	for _, char := range line[:start] {
+		if char == '\t' {
+			padding.WriteRune('\t')
+		} else {
+			padding.WriteRune(' ')
+		}
+	}
+
+	return padding.String() + renderer.colorize(strings.Repeat("^", width))
+}
+
+func (renderer *ErrorRenderer) colorize(text string) string {
+	if renderer.Color {
+		return makeStringRed(text)
+	}
+
+	return text
+}
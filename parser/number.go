@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// Number keeps the literal of a number exactly as it was in the input. The
+// parser produces it instead of int and float64 with the UseNumber option,
+// so that big integers and decimal amounts are not rounded.
+type Number string
+
+func (number Number) String() string {
+	return string(number)
+}
+
+func (number Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(number), 10, 64)
+}
+
+func (number Number) Uint64() (uint64, error) {
+	return strconv.ParseUint(string(number), 10, 64)
+}
+
+func (number Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(number), 64)
+}
+
+// BigInt returns the number as an integer of any size. A number with an
+// exponent or a fraction is accepted as long as its value is integral, like
+// 1.5e3.
+func (number Number) BigInt() (*big.Int, error) {
+	if integer, ok := new(big.Int).SetString(string(number), 10); ok {
+		return integer, nil
+	}
+
+	float, err := number.BigFloat()
+	if err != nil {
+		return nil, err
+	}
+
+	if float.IsInt() == false {
+		return nil, fmt.Errorf("number %s is not an integer", number)
+	}
+
+	integer, _ := float.Int(nil)
+
+	return integer, nil
+}
+
+// BigFloat returns the number with a precision big enough to hold all of the
+// digits of the literal.
+func (number Number) BigFloat() (*big.Float, error) {
+	float, _, err := big.ParseFloat(string(number), 10, literalPrecision(string(number)), big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("number %s is not valid: %w", number, err)
+	}
+
+	return float, nil
+}
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// OrderedObject is an object that keeps its members in the order in which
+// they appear in the input. The parser produces it instead of map[string]any
+// with the OrderedObjects option. The zero value is an empty object.
+type OrderedObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func NewOrderedObject() *OrderedObject {
+	return &OrderedObject{values: make(map[string]any)}
+}
+
+// Set adds a member at the end of the object. The value of an existing
+// member is replaced in its place.
+func (object *OrderedObject) Set(key string, value any) {
+	if object.values == nil {
+		object.values = make(map[string]any)
+	}
+
+	if _, wasFound := object.values[key]; wasFound == false {
+		object.keys = append(object.keys, key)
+	}
+	object.values[key] = value
+}
+
+func (object *OrderedObject) Get(key string) (any, bool) {
+	value, wasFound := object.values[key]
+
+	return value, wasFound
+}
+
+func (object *OrderedObject) Delete(key string) {
+	if _, wasFound := object.values[key]; wasFound == false {
+		return
+	}
+
+	delete(object.values, key)
+	object.keys = slices.DeleteFunc(object.keys, func(existing string) bool { return existing == key })
+}
+
+func (object *OrderedObject) Len() int {
+	return len(object.keys)
+}
+
+// Keys returns the keys in the order of the members. The slice is shared with
+// the object and must not be modified.
+func (object *OrderedObject) Keys() []string {
+	return object.keys
+}
+
+// Map returns the members without their order. The map is shared with the
+// object and must not be modified.
+func (object *OrderedObject) Map() map[string]any {
+	return object.values
+}
+
+// String formats the members in their order, like {name:Joe age:88}.
+func (object *OrderedObject) String() string {
+	var builder strings.Builder
+	builder.WriteByte('{')
+
+	for idx, key := range object.keys {
+		if idx > 0 {
+			builder.WriteByte(' ')
+		}
+		fmt.Fprintf(&builder, "%s:%v", key, object.values[key])
+	}
+	builder.WriteByte('}')
+
+	return builder.String()
+}
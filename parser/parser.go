@@ -8,28 +8,46 @@ import (
 	"sw/json-parser/token"
 )
 
-type ParserResult struct {
-	SingleMap map[string]any
-	MapArray  []map[string]any
-}
-
-func (parserResult *ParserResult) IsSingleMap() bool {
-	return parserResult.SingleMap != nil
-}
-
-func (parserResult *ParserResult) IsMapArray() bool {
-	return parserResult.MapArray != nil
-}
-
 type Parser struct {
 	lexer        *lexer.Lexer
-    errorHandler *ErrorHandler
+	options      ParserOptions
+	errorHandler *ErrorHandler
 	currentToken token.Token
 	peekToken    token.Token
+	// errors the lexer reported while reading the peek token, they get
+	// reported once the token becomes the current one
+	peekErrors           []lexer.LexerError
+	currentTokenReported bool
+	// set once the first top-level value was parsed
+	started bool
+	// path of the value being parsed and the positions of the values parsed
+	// so far, only tracked with the TrackPositions option
+	path      []any
+	positions map[string]token.Token
+	// depth counts the brackets and braces opened up to the current token
+	depth int
+	// nodes counts the values of the top-level value being parsed
+	nodes int
 }
 
-func New(lexer *lexer.Lexer) *Parser {
+// New creates a parser reading tokens from the given lexer. Options are
+// optional, only the first one passed is used.
+func New(lexer *lexer.Lexer, options ...ParserOptions) *Parser {
 	parser := Parser{lexer: lexer, errorHandler: &ErrorHandler{}}
+	if len(options) > 0 {
+		parser.options = options[0]
+	}
+	parser.options.Limits.applyTo(lexer)
+	if parser.options.ColumnUnit != 0 {
+		lexer.SetColumnUnit(parser.options.ColumnUnit)
+	}
+	if parser.options.ReplaceInvalidUTF8 {
+		lexer.SetReplaceInvalidUTF8(true)
+	}
+	if parser.options.Strict {
+		lexer.SetRejectBOM(true)
+		lexer.SetStrictWhitespace(true)
+	}
 
 	parser.nextToken()
 	parser.nextToken()
@@ -39,38 +57,75 @@ func New(lexer *lexer.Lexer) *Parser {
 
 func (parser *Parser) nextToken() {
 	parser.currentToken = parser.peekToken
+	switch parser.currentToken.Type {
+	case token.LBRACE, token.LSQUARE_BRACE:
+		parser.depth++
+	case token.RBRACE, token.RSQUARE_BRACE:
+		parser.depth--
+	}
+
+	parser.currentTokenReported = len(parser.peekErrors) > 0
+	for _, lexerError := range parser.peekErrors {
+		parser.errorHandler.AddLexerError(&lexerError, &parser.currentToken)
+	}
+
 	parser.peekToken = parser.lexer.ReadToken()
+	parser.peekErrors = parser.lexer.TakeErrors()
 }
 
 func (parser *Parser) Parse() (*ParserResult, ParserErrors) {
-	if parser.currentToken.Literal == token.LBRACE {
-		result := parser.parseObject()
+	if parser.started {
+		// move past the last token of the previously parsed value
+		parser.nextToken()
+	}
+	parser.started = true
+	parser.nodes = 0
+	if parser.options.TrackPositions {
+		parser.positions = make(map[string]token.Token)
+	}
 
-		return &ParserResult{SingleMap: result}, parser.errorHandler.GetErrors()
-	} else if parser.currentToken.Type == token.LSQUARE_BRACE {
-		result := parser.parseArray()
+	value := parser.parseJson()
+	if parser.options.AllowTrailingData == false && parser.errorHandler.HasErrors() == false {
+		parser.nextToken()
+		if parser.currentToken.Type != token.EoF && parser.currentTokenReported == false {
+			parser.errorHandler.AddTokenError(CodeTrailingData, "Unexpected data after top-level value.", &parser.currentToken)
+		}
+	}
 
-		// NOTE: is there a better way of converting []any to []map[string]any?
-		var mapResult []map[string]any
-		for _, res := range result {
-			conv, ok := res.(map[string]any)
-			if ok == false {
-                parser.errorHandler.AddPlainError("Error while converting array of objects into a map. Conversion from 'any' type was not possible.")
+	if parser.errorHandler.HasErrors() {
+		return nil, parser.errorHandler.GetErrors()
+	}
 
-				return nil, parser.errorHandler.GetErrors()
-			}
-			mapResult = append(mapResult, conv)
-		}
+	result := newParserResult(value)
+	result.positions = parser.positions
 
-		return &ParserResult{MapArray: mapResult}, parser.errorHandler.GetErrors()
+	return result, nil
+}
+
+// More reports whether there is another top-level value left to parse. It is
+// meant for parsing concatenated documents together with AllowTrailingData.
+func (parser *Parser) More() bool {
+	if parser.errorHandler.HasErrors() {
+		return false
+	}
+
+	if parser.started {
+		return parser.peekToken.Type != token.EoF
 	}
 
-    parser.errorHandler.AddTokenError(fmt.Sprintf("The input has to begin either with '{' or with '[', but got '%s' instead.", parser.currentToken.Literal), &parser.currentToken)
-    
-    return nil, parser.errorHandler.GetErrors()
+	return parser.currentToken.Type != token.EoF
 }
 
 func (parser *Parser) parseJson() any {
+	parser.nodes++
+	if parser.exceedsLimit(parser.nodes, parser.options.Limits.MaxNodes, CodeTooManyNodes, "Input has more than the limit of %d values.") {
+		return nil
+	}
+
+	if parser.options.TrackPositions {
+		parser.positions[FormatPath(parser.path)] = parser.currentToken
+	}
+
 	switch parser.currentToken.Type {
 	case token.LBRACE:
 		return parser.parseObject()
@@ -87,102 +142,269 @@ func (parser *Parser) parseJson() any {
 	case token.NULL:
 		return nil
 	default:
-		// Handle negative numbers
-		if parser.currentToken.Type == token.MINUS && parser.peekExpected(token.NUMBER) {
-			return parser.parseNegativeNumber()
-		}
+		parser.reportInvalidValue()
+
+		return nil
+	}
+}
+
+// reportInvalidValue reports the current token, which cannot start a value,
+// like the end of the input or an unknown token.
+func (parser *Parser) reportInvalidValue() {
+	if parser.currentToken.Type == token.EoF {
+		parser.errorHandler.AddTokenError(CodeUnexpectedEOF, "Unexpected end of input, expected a value.", &parser.currentToken)
 
-        parser.errorHandler.AddTokenError("Unknown token", &parser.currentToken)
+		return
+	}
 
-        return nil
+	// tokens the lexer already complained about, like malformed numbers,
+	// should not be reported twice
+	if parser.currentTokenReported == false {
+		parser.errorHandler.AddTokenError(CodeUnexpectedToken, "Unknown token", &parser.currentToken)
 	}
 }
 
 func (parser *Parser) parseArray() []any {
+	if parser.exceedsMaxDepth(parser.depth) {
+		return nil
+	}
+
 	jsonArr := []any{}
+	openingToken := parser.currentToken
 
 	// consume '['
 	parser.nextToken()
 
 	for parser.currentToken.Type != token.RSQUARE_BRACE {
+		if parser.errorHandler.HasErrors() {
+			return nil
+		}
+
+		if parser.currentToken.Type == token.EoF {
+			parser.errorHandler.AddTokenError(CodeUnclosedArray, fmt.Sprintf("Array opened at line %d column %d was never closed.", openingToken.Line, openingToken.Column), &parser.currentToken)
+
+			return nil
+		}
+
+		if parser.exceedsLimit(len(jsonArr)+1, parser.options.Limits.MaxArrayLength, CodeArrayTooLong, "Array has more than the limit of %d values.") {
+			return nil
+		}
+
+		parser.pushPathSegment(len(jsonArr))
 		parsedJson := parser.parseJson()
+		parser.popPathSegment()
 
 		jsonArr = append(jsonArr, parsedJson)
 
 		// consume array value
 		parser.nextToken()
 
-		if parser.currentToken.Type != token.RSQUARE_BRACE {
-			// consume ',' if we are not at the end of the object
-			parser.nextToken()
+		if parser.consumeComma(token.RSQUARE_BRACE) == false {
+			return nil
 		}
 	}
 
 	return jsonArr
 }
 
-func (parser *Parser) parseObject() map[string]any {
-	jsonObj := make(map[string]any)
+// parseObject returns a map[string]any, or an *OrderedObject with the
+// OrderedObjects option.
+func (parser *Parser) parseObject() any {
+	if parser.exceedsMaxDepth(parser.depth) {
+		return nil
+	}
+
+	var jsonObj objectBuilder = mapObject{}
+	if parser.options.OrderedObjects {
+		jsonObj = NewOrderedObject()
+	}
+	openingToken := parser.currentToken
+	members := 0
+	// the first occurrence of every key and the keys whose values were
+	// collected, only created for the duplicate key policies that need them
+	var keyTokens map[string]token.Token
+	var collected map[string]bool
 
 	// consume '{'
 	parser.nextToken()
 
 	for parser.currentToken.Type != token.RBRACE {
-		if parser.currentToken.Type != token.STRING {
+		if parser.errorHandler.HasErrors() {
+			return nil
+		}
+
+		if parser.currentToken.Type == token.EoF {
+			parser.errorHandler.AddTokenError(CodeUnclosedObject, fmt.Sprintf("Object opened at line %d column %d was never closed.", openingToken.Line, openingToken.Column), &parser.currentToken)
+
+			return nil
+		}
 
-            parser.errorHandler.AddTokenError("Key value has to be of type string. Did you add quotation marks around the key value?", &parser.currentToken)
+		members++
+		if parser.exceedsLimit(members, parser.options.Limits.MaxMembers, CodeTooManyMembers, "Object has more than the limit of %d members.") {
+			return nil
+		}
+
+		if parser.currentToken.Type != token.STRING {
+			parser.errorHandler.AddTokenError(CodeInvalidKey, "Key value has to be of type string. Did you add quotation marks around the key value?", &parser.currentToken)
 
-            return nil
+			return nil
 		}
 
 		key := parser.currentToken.Literal
+		keyToken := parser.currentToken
+		if parser.options.DuplicateKeys == DuplicateKeysError {
+			if firstToken, ok := keyTokens[key]; ok {
+				parser.errorHandler.AddTokenError(CodeDuplicateKey, fmt.Sprintf("Duplicate key %q, it was already defined at line %d column %d.", key, firstToken.Line, firstToken.Column), &keyToken)
+
+				return nil
+			}
+
+			if keyTokens == nil {
+				keyTokens = make(map[string]token.Token)
+			}
+			keyTokens[key] = keyToken
+		}
 
 		// move past the key string
 		parser.nextToken()
 
 		if parser.currentToken.Type != token.COLON {
-            parser.errorHandler.AddTokenError("Key value has to be followed by a colon, but got " + string(parser.currentToken.Type), &parser.currentToken)
-            
-            return nil
+			parser.errorHandler.AddTokenError(CodeMissingColon, "Key value has to be followed by a colon, but got "+string(parser.currentToken.Type), &parser.currentToken)
+
+			return nil
 		}
 
 		// consume ':'
 		parser.nextToken()
 
+		parser.pushPathSegment(key)
 		value := parser.parseJson()
-
-		jsonObj[key] = value
+		parser.popPathSegment()
+
+		existing, isDuplicate := jsonObj.Get(key)
+		switch {
+		case isDuplicate == false || parser.options.DuplicateKeys == DuplicateKeysLastWins:
+			jsonObj.Set(key, value)
+		case parser.options.DuplicateKeys == DuplicateKeysCollect:
+			if collected[key] {
+				jsonObj.Set(key, append(existing.([]any), value))
+			} else {
+				jsonObj.Set(key, []any{existing, value})
+				if collected == nil {
+					collected = make(map[string]bool)
+				}
+				collected[key] = true
+			}
+		}
 
 		// consume value
 		parser.nextToken()
 
-		if parser.currentToken.Type != token.RBRACE {
-			// consume ',' if we are not at the end of the object
-			parser.nextToken()
+		if parser.consumeComma(token.RBRACE) == false {
+			return nil
 		}
 	}
 
+	if object, ok := jsonObj.(mapObject); ok {
+		return map[string]any(object)
+	}
+
 	return jsonObj
 }
 
-func (parser *Parser) parseString() string {
-	return parser.currentToken.Literal
+// objectBuilder is implemented by the objects parseObject fills, so that the
+// duplicate key policies work the same for both of them.
+type objectBuilder interface {
+	Get(key string) (any, bool)
+	Set(key string, value any)
 }
 
-func (parser *Parser) parseNegativeNumber() interface{} {
-	// consume '-'
-	parser.nextToken()
+// mapObject is a plain map, which does not pay for keeping the order of the
+// members when the OrderedObjects option is not set.
+type mapObject map[string]any
+
+func (object mapObject) Get(key string) (any, bool) {
+	value, wasFound := object[key]
+
+	return value, wasFound
+}
+
+func (object mapObject) Set(key string, value any) {
+	object[key] = value
+}
+
+// exceedsMaxDepth reports an error at the current token, which opens an
+// array or an object, when it is nested deeper than allowed.
+func (parser *Parser) exceedsMaxDepth(depth int) bool {
+	maxDepth := parser.options.maxDepth()
+	if maxDepth < 0 || depth <= maxDepth {
+		return false
+	}
+
+	parser.errorHandler.AddTokenError(CodeMaxDepth, fmt.Sprintf("Maximum nesting depth of %d exceeded.", maxDepth), &parser.currentToken)
+
+	return true
+}
+
+// exceedsLimit reports an error at the current token when the count went
+// over the limit. The message gets the limit formatted into it.
+func (parser *Parser) exceedsLimit(count int, limit int, code ErrorCode, message string) bool {
+	if limit <= 0 || count <= limit {
+		return false
+	}
 
-	parsedValue := parser.parseNumber()
+	parser.errorHandler.AddTokenError(code, fmt.Sprintf(message, limit), &parser.currentToken)
 
-	switch val := parsedValue.(type) {
-	case int:
-		return -val
-	case float64:
-		return -val
+	return true
+}
+
+func (parser *Parser) pushPathSegment(segment any) {
+	if parser.options.TrackPositions {
+		parser.path = append(parser.path, segment)
 	}
+}
 
-    return nil
+func (parser *Parser) popPathSegment() {
+	if parser.options.TrackPositions {
+		parser.path = parser.path[:len(parser.path)-1]
+	}
+}
+
+// consumeComma moves past the ',' separating values of an array or an object.
+// A missing separator is always an error, a trailing comma right before the
+// closing token is only an error in strict mode.
+func (parser *Parser) consumeComma(closingType token.TokenType) bool {
+	if parser.errorHandler.HasErrors() {
+		// the value before got already reported
+		return false
+	}
+
+	switch parser.currentToken.Type {
+	case closingType, token.EoF:
+		// a missing closing token gets reported by the caller
+		return true
+	case token.COMMA:
+		commaToken := parser.currentToken
+		parser.nextToken()
+
+		if parser.currentToken.Type == closingType && parser.options.Strict {
+			parser.errorHandler.AddTokenError(CodeTrailingComma, "Trailing comma is not allowed in strict mode.", &commaToken)
+
+			return false
+		}
+
+		return true
+	}
+
+	if parser.currentTokenReported == false {
+		parser.errorHandler.AddTokenError(CodeMissingComma, fmt.Sprintf("Expected ',' or '%s', but got '%s' instead.", closingType, parser.currentToken.Literal), &parser.currentToken)
+	}
+
+	return false
+}
+
+func (parser *Parser) parseString() string {
+	return parser.currentToken.Literal
 }
 
 func (parser *Parser) parseNumber() interface{} {
@@ -190,26 +412,25 @@ func (parser *Parser) parseNumber() interface{} {
 	// try to parse it as a float.
 
 	literal := parser.currentToken.Literal
+	if parser.options.UseNumber {
+		return Number(literal)
+	}
 
 	parsedInt, error := strconv.Atoi(literal)
 	if error == nil {
 		return parsedInt
 	}
 
+	if parser.options.BigNumbers != BigNumbersOff {
+		return parser.parseBigNumber(literal)
+	}
+
 	parsedFloat, error := strconv.ParseFloat(literal, 64)
 	if error == nil {
 		return parsedFloat
 	}
 
-    parser.errorHandler.AddTokenError("It was not possible to parse the token literal as either int or float.", &parser.currentToken)
-
-    return nil
-}
+	parser.errorHandler.AddTokenError(CodeInvalidNumber, "It was not possible to parse the token literal as either int or float.", &parser.currentToken)
 
-func (parser *Parser) peekExpected(expectedToken token.TokenType) bool {
-	if parser.peekToken.Type == expectedToken {
-		return true
-	}
-
-	return false
+	return nil
 }
@@ -2,36 +2,138 @@ package parser
 
 import (
 	"fmt"
+	"strings"
+
+	"sw/json-parser/lexer"
 	"sw/json-parser/token"
 )
 
-type ParserErrors []string
-
-type ErrorHandler struct {
-    errors []string
-}
+// ErrorCode identifies the kind of a syntax error. It implements the error
+// interface, so that errors.Is(err, parser.CodeTrailingComma) can be used to
+// match on the kind of any error returned by the parser.
+type ErrorCode string
 
 const (
-    ANSI_RESET = "\033[0m"
-    ANSI_RED = "\033[31m"
+	CodeUnexpectedToken ErrorCode = "unexpected_token"
+	CodeUnexpectedEOF   ErrorCode = "unexpected_eof"
+	CodeUnclosedArray   ErrorCode = "unclosed_array"
+	CodeUnclosedObject  ErrorCode = "unclosed_object"
+	CodeInvalidKey      ErrorCode = "invalid_key"
+	CodeMissingColon    ErrorCode = "missing_colon"
+	CodeMissingComma    ErrorCode = "missing_comma"
+	CodeTrailingComma   ErrorCode = "trailing_comma"
+	CodeTrailingData    ErrorCode = "trailing_data"
+	CodeMaxDepth        ErrorCode = "max_depth"
+	CodeTooManyMembers  ErrorCode = "too_many_members"
+	CodeArrayTooLong    ErrorCode = "array_too_long"
+	CodeTooManyNodes    ErrorCode = "too_many_nodes"
+	CodeDuplicateKey    ErrorCode = "duplicate_key"
+
+	// codes reported by the lexer
+	CodeInvalidEscape      ErrorCode = lexer.CodeInvalidEscape
+	CodeInvalidSurrogate   ErrorCode = lexer.CodeInvalidSurrogate
+	CodeControlCharacter   ErrorCode = lexer.CodeControlCharacter
+	CodeUnterminatedString ErrorCode = lexer.CodeUnterminatedString
+	CodeInvalidNumber      ErrorCode = lexer.CodeInvalidNumber
+	CodeReadError          ErrorCode = lexer.CodeReadError
+	CodeInputTooLarge      ErrorCode = lexer.CodeInputTooLarge
+	CodeStringTooLong      ErrorCode = lexer.CodeStringTooLong
+	CodeNumberTooLong      ErrorCode = lexer.CodeNumberTooLong
+	CodeInvalidUTF8        ErrorCode = lexer.CodeInvalidUTF8
+	CodeUnexpectedBOM      ErrorCode = lexer.CodeUnexpectedBOM
 )
 
-func makeStringRed(message string) string {
-    return fmt.Sprintf("%s%s%s", ANSI_RED, message, ANSI_RESET)
+func (code ErrorCode) Error() string {
+	return string(code)
+}
+
+// SyntaxError describes a single problem found in the input. Literal holds
+// the literal of the token the error was found in. The end position points
+// right after the offending part of the input.
+type SyntaxError struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	Line      int       `json:"line"`
+	Column    int       `json:"column"`
+	Offset    int       `json:"offset"`
+	EndLine   int       `json:"end_line"`
+	EndColumn int       `json:"end_column"`
+	EndOffset int       `json:"end_offset"`
+	Literal   string    `json:"literal"`
 }
 
-func (errorHandler *ErrorHandler) AddTokenError(errorMessage string, token *token.Token) {
-    errorPosition := fmt.Sprintf("%s line %d and column %d near token literal '%s'.", makeStringRed("PARSER ERROR:"), token.Line, token.Column, token.Literal)
-    error := fmt.Sprintf("%s\n%s", errorPosition, errorMessage)
+func (syntaxError *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d and column %d near token literal '%s': %s", syntaxError.Line, syntaxError.Column, syntaxError.Literal, syntaxError.Message)
+}
 
-    errorHandler.errors = append(errorHandler.errors, error)
+func (syntaxError *SyntaxError) Unwrap() error {
+	return syntaxError.Code
 }
 
-func (errorHandler *ErrorHandler) AddPlainError(errorMessage string) {
-    errorHandler.errors = append(errorHandler.errors, errorMessage)
+// ParserErrors holds every error found while parsing. Note that a nil
+// ParserErrors stored in an error interface is not a nil error, so compare
+// the ParserErrors returned by Parse with nil before converting it.
+type ParserErrors []*SyntaxError
+
+func (parserErrors ParserErrors) Error() string {
+	messages := make([]string, len(parserErrors))
+	for idx, syntaxError := range parserErrors {
+		messages[idx] = syntaxError.Error()
+	}
+
+	return strings.Join(messages, "\n")
 }
 
-func (errorHandler *ErrorHandler) GetErrors() []string {
-    return errorHandler.errors
+func (parserErrors ParserErrors) Unwrap() []error {
+	errors := make([]error, len(parserErrors))
+	for idx, syntaxError := range parserErrors {
+		errors[idx] = syntaxError
+	}
+
+	return errors
 }
 
+type ErrorHandler struct {
+	errors ParserErrors
+}
+
+func (errorHandler *ErrorHandler) AddTokenError(code ErrorCode, errorMessage string, token *token.Token) {
+	syntaxError := SyntaxError{
+		Code:      code,
+		Message:   errorMessage,
+		Line:      token.Line,
+		Column:    token.Column,
+		Offset:    token.Offset,
+		EndLine:   token.EndLine,
+		EndColumn: token.EndColumn,
+		EndOffset: token.EndOffset,
+		Literal:   token.Literal,
+	}
+
+	errorHandler.errors = append(errorHandler.errors, &syntaxError)
+}
+
+// AddLexerError reports an error the lexer found while reading the given token.
+func (errorHandler *ErrorHandler) AddLexerError(lexerError *lexer.LexerError, token *token.Token) {
+	syntaxError := SyntaxError{
+		Code:      ErrorCode(lexerError.Code),
+		Message:   lexerError.Message,
+		Line:      lexerError.Line,
+		Column:    lexerError.Column,
+		Offset:    lexerError.Offset,
+		EndLine:   lexerError.EndLine,
+		EndColumn: lexerError.EndColumn,
+		EndOffset: lexerError.EndOffset,
+		Literal:   token.Literal,
+	}
+
+	errorHandler.errors = append(errorHandler.errors, &syntaxError)
+}
+
+func (errorHandler *ErrorHandler) HasErrors() bool {
+	return len(errorHandler.errors) > 0
+}
+
+func (errorHandler *ErrorHandler) GetErrors() ParserErrors {
+	return errorHandler.errors
+}
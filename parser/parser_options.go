@@ -0,0 +1,110 @@
+package parser
+
+import "sw/json-parser/lexer"
+
+// DefaultMaxDepth is the nesting depth allowed when ParserOptions.MaxDepth is
+// not set.
+const DefaultMaxDepth = 1000
+
+// DuplicateKeyPolicy decides what happens with a member whose key already
+// appeared in the same object.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeysLastWins keeps the value of the last occurrence.
+	DuplicateKeysLastWins DuplicateKeyPolicy = iota
+	// DuplicateKeysFirstWins keeps the value of the first occurrence.
+	DuplicateKeysFirstWins
+	// DuplicateKeysError reports the second occurrence as CodeDuplicateKey.
+	DuplicateKeysError
+	// DuplicateKeysCollect keeps the values of all occurrences in a []any.
+	DuplicateKeysCollect
+)
+
+type ParserOptions struct {
+	// Strict enforces the exact JSON grammar. Without it, a trailing comma
+	// after the last value of an array or an object is tolerated, and so are
+	// a UTF-8 byte order mark at the start of the input and vertical tabs and
+	// form feeds as whitespace.
+	Strict bool
+
+	// ReplaceInvalidUTF8 replaces invalid UTF-8 byte sequences inside of
+	// strings with U+FFFD instead of reporting them as CodeInvalidUTF8.
+	ReplaceInvalidUTF8 bool
+
+	// AllowTrailingData stops the parser right after the first top-level value
+	// instead of reporting anything that follows it as an error. Calling Parse
+	// again parses the next value, which allows reading concatenated documents.
+	AllowTrailingData bool
+
+	// TrackPositions records the position of every parsed value, which can be
+	// looked up afterwards with ParserResult.Position.
+	TrackPositions bool
+
+	// ColumnUnit decides whether the columns of the tokens and errors count
+	// bytes, which is the default, code points or UTF-16 code units.
+	ColumnUnit lexer.ColumnUnit
+
+	// MaxDepth limits how deeply arrays and objects can be nested, so that
+	// untrusted input cannot exhaust the stack. With 0 DefaultMaxDepth is
+	// used, a negative value disables the limit.
+	MaxDepth int
+
+	// OrderedObjects makes the parser produce an *OrderedObject instead of a
+	// map[string]any for every object, which keeps the members in the order
+	// of the input. The SingleMap and MapArray shortcuts are not filled then.
+	OrderedObjects bool
+
+	// UseNumber makes the parser produce a Number keeping the literal of every
+	// number instead of converting it into an int or a float64.
+	UseNumber bool
+
+	// BigNumbers keeps the exact value of numbers that do not fit into an
+	// int, see BigNumberMode. UseNumber takes precedence over it.
+	BigNumbers BigNumberMode
+	// BigFloatPrecision is the precision in bits of the *big.Float values of
+	// BigNumbersFloat. With 0 it is big enough for all digits of a number.
+	BigFloatPrecision uint
+
+	// DuplicateKeys decides what happens with repeated keys of an object, by
+	// default the last value wins.
+	DuplicateKeys DuplicateKeyPolicy
+
+	// Limits caps the size of the input and the amount of values in it.
+	Limits ParserLimits
+}
+
+// ParserLimits protects against hostile payloads by rejecting them while
+// they are being parsed, before they are read into memory. A limit of 0
+// means no limit.
+type ParserLimits struct {
+	// MaxInputSize is the maximum amount of bytes read from the input.
+	MaxInputSize int
+	// MaxStringLength is the maximum length of a decoded string in bytes.
+	MaxStringLength int
+	// MaxNumberLength is the maximum amount of characters of a number.
+	MaxNumberLength int
+	// MaxMembers is the maximum amount of members of a single object.
+	MaxMembers int
+	// MaxArrayLength is the maximum amount of values of a single array.
+	MaxArrayLength int
+	// MaxNodes is the maximum amount of values, containers included, in a
+	// top-level value. The ElementIterator counts them per element.
+	MaxNodes int
+}
+
+// applyTo passes the limits checked by the lexer on to it.
+func (limits ParserLimits) applyTo(l *lexer.Lexer) {
+	lexerLimits := lexer.Limits{MaxInputSize: limits.MaxInputSize, MaxStringLength: limits.MaxStringLength, MaxNumberLength: limits.MaxNumberLength}
+	if lexerLimits != (lexer.Limits{}) {
+		l.SetLimits(lexerLimits)
+	}
+}
+
+func (options ParserOptions) maxDepth() int {
+	if options.MaxDepth == 0 {
+		return DefaultMaxDepth
+	}
+
+	return options.MaxDepth
+}
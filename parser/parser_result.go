@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"math/big"
+
+	"sw/json-parser/token"
+)
+
+// Kind describes which JSON type was parsed as the top-level value.
+type Kind int
+
+const (
+	KindNull Kind = iota
+	KindBool
+	KindNumber
+	KindString
+	KindArray
+	KindObject
+)
+
+var kindNames = map[Kind]string{
+	KindNull:   "null",
+	KindBool:   "bool",
+	KindNumber: "number",
+	KindString: "string",
+	KindArray:  "array",
+	KindObject: "object",
+}
+
+func (kind Kind) String() string {
+	return kindNames[kind]
+}
+
+type ParserResult struct {
+	// Value holds the top-level value, which can be a map[string]any, []any,
+	// string, int, float64, bool or nil. Depending on the options objects can
+	// be an *OrderedObject and numbers a Number, *big.Int, *big.Rat or
+	// *big.Float.
+	Value any
+
+	// SingleMap and MapArray are shortcuts for the most common inputs, a single
+	// object or an array made only of objects.
+	SingleMap map[string]any
+	MapArray  []map[string]any
+
+	positions map[string]token.Token
+}
+
+func newParserResult(value any) *ParserResult {
+	result := ParserResult{Value: value}
+
+	switch val := value.(type) {
+	case map[string]any:
+		result.SingleMap = val
+	case []any:
+		mapArray := make([]map[string]any, 0, len(val))
+		for _, element := range val {
+			object, ok := element.(map[string]any)
+			if ok == false {
+				return &result
+			}
+			mapArray = append(mapArray, object)
+		}
+		result.MapArray = mapArray
+	}
+
+	return &result
+}
+
+func (parserResult *ParserResult) Kind() Kind {
+	return kindOf(parserResult.Value)
+}
+
+// Position returns the first token of the value found at the path, which is
+// only available when the input was parsed with the TrackPositions option.
+func (parserResult *ParserResult) Position(path ...any) (token.Token, bool) {
+	position, wasFound := parserResult.positions[FormatPath(path)]
+
+	return position, wasFound
+}
+
+func (parserResult *ParserResult) IsSingleMap() bool {
+	return parserResult.SingleMap != nil
+}
+
+func (parserResult *ParserResult) IsMapArray() bool {
+	return parserResult.MapArray != nil
+}
+
+func kindOf(value any) Kind {
+	switch value.(type) {
+	case map[string]any, *OrderedObject:
+		return KindObject
+	case []any:
+		return KindArray
+	case string:
+		return KindString
+	case int, float64, Number, *big.Int, *big.Rat, *big.Float:
+		return KindNumber
+	case bool:
+		return KindBool
+	}
+
+	return KindNull
+}
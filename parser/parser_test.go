@@ -1,7 +1,16 @@
 package parser
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
 	"testing"
+	"time"
 
 	"sw/json-parser/lexer"
 )
@@ -368,3 +377,1499 @@ func TestParserArrayOfObjects(t *testing.T) {
 
 	}
 }
+
+func TestParserDecodesEscapedStrings(t *testing.T) {
+	input := `{"quote": "say \"hi\"", "path": "C:\\temp\\new", "emoji": "\ud83d\ude00"}`
+
+	lexer := lexer.New(input)
+	parser := New(lexer)
+
+	parserResult, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+
+	expectedMap := map[string]string{
+		"quote": `say "hi"`,
+		"path":  `C:\temp\new`,
+		"emoji": "😀",
+	}
+
+	for key, value := range expectedMap {
+		if parserResult.SingleMap[key] != value {
+			t.Fatalf("Parser returned an unexpected key-value pair. Expected: %q->%q, but got %q->%q", key, value, key, parserResult.SingleMap[key])
+		}
+	}
+}
+
+func TestParserReportsMalformedEscapes(t *testing.T) {
+	input := `{"name": "Jo\qe"}`
+
+	lexer := lexer.New(input)
+	parser := New(lexer)
+
+	_, err := parser.Parse()
+	if len(err) != 1 {
+		t.Fatalf("Expected exactly one error, but got %q", err)
+	}
+
+	if err[0].Code != CodeInvalidEscape || err[0].Line != 1 || err[0].Column != 13 {
+		t.Fatalf("Error does not point at the escape sequence. Got %q", err[0])
+	}
+}
+
+func TestParserReportsEveryTruncation(t *testing.T) {
+	input := `{"name": "Joe \"J\" Doe", "age": -88, "salary": 99.78, "tags": ["a", "\u00e9"], "address": {"city": "Oslo", "zip": null}, "active": true}`
+
+	for end := 0; end < len(input); end++ {
+		truncated := input[:end]
+		done := make(chan ParserErrors, 1)
+
+		go func() {
+			_, err := New(lexer.New(truncated)).Parse()
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Fatalf("Parser did not report an error for the truncated input %q", truncated)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Parser did not terminate for the truncated input %q", truncated)
+		}
+	}
+}
+
+func TestParserReportsUnclosedContainers(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"[1, 2", "Array opened at line 1 column 1 was never closed."},
+		{"{\n  \"a\": [1,\n  2", "Array opened at line 2 column 8 was never closed."},
+		{`{"a": 1,`, "Object opened at line 1 column 1 was never closed."},
+		{`{"a": "text`, "Unterminated string starting at line 1 column 7."},
+		{`{"a":`, "Unexpected end of input, expected a value."},
+	}
+
+	for i, test := range tests {
+		_, err := New(lexer.New(test.input)).Parse()
+		if len(err) != 1 {
+			t.Fatalf("tests[%d] - expected exactly one error, but got %q", i, err)
+		}
+
+		if err[0].Message != test.expectedError {
+			t.Fatalf("tests[%d] - expected error %q, but got %q", i, test.expectedError, err[0])
+		}
+	}
+}
+
+func TestParserNumbersWithExponents(t *testing.T) {
+	input := `{"big": 1e10, "small": 2.5E-3, "zero": -0.0e+1, "negative": -1.5}`
+
+	lexer := lexer.New(input)
+	parser := New(lexer)
+
+	parserResult, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+
+	expectedMap := map[string]float64{
+		"big":      1e10,
+		"small":    0.0025,
+		"zero":     0,
+		"negative": -1.5,
+	}
+
+	for key, value := range expectedMap {
+		if parserResult.SingleMap[key] != value {
+			t.Fatalf("Parser returned an unexpected key-value pair. Expected: %q->%f, but got %q->%v", key, value, key, parserResult.SingleMap[key])
+		}
+	}
+}
+
+func TestParserReportsMalformedNumbersOnce(t *testing.T) {
+	input := `{"age": 007}`
+
+	lexer := lexer.New(input)
+	parser := New(lexer)
+
+	_, err := parser.Parse()
+	if len(err) != 1 {
+		t.Fatalf("Expected exactly one error, but got %q", err)
+	}
+
+	if err[0].Message != "Leading zeros are not allowed in numbers." {
+		t.Fatalf("Unexpected error message. Got %q", err[0])
+	}
+}
+
+func TestParserTopLevelValues(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedKind  Kind
+		expectedValue any
+	}{
+		{`"Joe"`, KindString, "Joe"},
+		{`88`, KindNumber, 88},
+		{`-99.78`, KindNumber, -99.78},
+		{`true`, KindBool, true},
+		{`false`, KindBool, false},
+		{`null`, KindNull, nil},
+	}
+
+	for i, test := range tests {
+		parserResult, err := New(lexer.New(test.input)).Parse()
+		if err != nil {
+			t.Fatalf("tests[%d] - Parser returned an error. Error: %q", i, err)
+		}
+
+		if parserResult.Kind() != test.expectedKind {
+			t.Fatalf("tests[%d] - kind is wrong. Expected=%s, but got=%s", i, test.expectedKind, parserResult.Kind())
+		}
+
+		if parserResult.Value != test.expectedValue {
+			t.Fatalf("tests[%d] - value is wrong. Expected=%v, but got=%v", i, test.expectedValue, parserResult.Value)
+		}
+
+		if parserResult.IsSingleMap() || parserResult.IsMapArray() {
+			t.Fatalf("tests[%d] - scalar value should be neither a single map nor a map array", i)
+		}
+	}
+}
+
+func TestParserArrayOfScalars(t *testing.T) {
+	input := `[1, "two", [3], {"four": 4}]`
+
+	parserResult, err := New(lexer.New(input)).Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+
+	if parserResult.Kind() != KindArray {
+		t.Fatalf("Parser result is not an array, got %s", parserResult.Kind())
+	}
+
+	if parserResult.IsMapArray() {
+		t.Fatalf("Array of mixed values should not be a map array")
+	}
+
+	values, ok := parserResult.Value.([]any)
+	if ok == false || len(values) != 4 {
+		t.Fatalf("Unexpected array value %v", parserResult.Value)
+	}
+
+	if values[0] != 1 || values[1] != "two" {
+		t.Fatalf("Unexpected array values %v", values)
+	}
+}
+
+func TestParserSingleMapKind(t *testing.T) {
+	parserResult, err := New(lexer.New(`{"name": "Joe"}`)).Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+
+	if parserResult.Kind() != KindObject || parserResult.IsSingleMap() == false {
+		t.Fatalf("Parser result is not a single map")
+	}
+}
+
+func TestParserRejectsTrailingData(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedColumn int
+	}{
+		{`{"a": 1} {"b": 2}`, 10},
+		{`[1]xyz`, 4},
+		{`"a" "b"`, 6},
+		{`{"a": 1}}`, 9},
+	}
+
+	for i, test := range tests {
+		_, err := New(lexer.New(test.input)).Parse()
+		if len(err) != 1 {
+			t.Fatalf("tests[%d] - expected exactly one error, but got %q", i, err)
+		}
+
+		if err[0].Code != CodeTrailingData || err[0].Column != test.expectedColumn {
+			t.Fatalf("tests[%d] - unexpected error %q", i, err[0])
+		}
+	}
+}
+
+func TestParserAllowsTrailingWhitespace(t *testing.T) {
+	_, err := New(lexer.New("{\"a\": 1}\n\t ")).Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+}
+
+func TestParserConcatenatedDocuments(t *testing.T) {
+	input := `{"id": 1} {"id": 2}
+[3] "four"`
+
+	parser := New(lexer.New(input), ParserOptions{AllowTrailingData: true})
+
+	var values []any
+	for parser.More() {
+		parserResult, err := parser.Parse()
+		if err != nil {
+			t.Fatalf("Parser returned an error. Error: %q", err)
+		}
+		values = append(values, parserResult.Value)
+	}
+
+	if len(values) != 4 {
+		t.Fatalf("Expected 4 documents, but got %d: %v", len(values), values)
+	}
+
+	if values[1].(map[string]any)["id"] != 2 || values[3] != "four" {
+		t.Fatalf("Unexpected documents %v", values)
+	}
+}
+
+func TestParserRejectsMissingCommas(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`[1 2 3]`, "line 1 and column 4 near token literal '2': Expected ',' or ']', but got '2' instead."},
+		{`{"a": 1 "b": 2}`, "line 1 and column 10 near token literal 'b': Expected ',' or '}', but got 'b' instead."},
+		{`[[1] [2]]`, "line 1 and column 6 near token literal '[': Expected ',' or ']', but got '[' instead."},
+	}
+
+	for _, options := range []ParserOptions{{}, {Strict: true}} {
+		for i, test := range tests {
+			_, err := New(lexer.New(test.input), options).Parse()
+			if len(err) != 1 {
+				t.Fatalf("tests[%d] - expected exactly one error, but got %q", i, err)
+			}
+
+			if err[0].Error() != test.expectedError {
+				t.Fatalf("tests[%d] - expected error %q, but got %q", i, test.expectedError, err[0])
+			}
+		}
+	}
+}
+
+func TestParserTrailingCommas(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedColumn int
+	}{
+		{`[1, 2,]`, 6},
+		{`{"a": 1,}`, 8},
+		{`{"orders": [{"name": "foo", "price": 11.99},]}`, 44},
+	}
+
+	for i, test := range tests {
+		_, err := New(lexer.New(test.input)).Parse()
+		if err != nil {
+			t.Fatalf("tests[%d] - lenient parser returned an error. Error: %q", i, err)
+		}
+
+		_, err = New(lexer.New(test.input), ParserOptions{Strict: true}).Parse()
+		if len(err) != 1 {
+			t.Fatalf("tests[%d] - expected exactly one error, but got %q", i, err)
+		}
+
+		if err[0].Code != CodeTrailingComma || err[0].Column != test.expectedColumn {
+			t.Fatalf("tests[%d] - unexpected error %q", i, err[0])
+		}
+	}
+}
+
+func TestParserStrictWhitespace(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedColumn int
+	}{
+		{"[1,\v2]", 4},
+		{"[1,\f2]", 4},
+		{"\f[1]", 1},
+	}
+
+	for i, test := range tests {
+		_, err := New(lexer.New(test.input)).Parse()
+		if err != nil {
+			t.Fatalf("tests[%d] - lenient parser returned an error. Error: %q", i, err)
+		}
+
+		_, err = New(lexer.New(test.input), ParserOptions{Strict: true}).Parse()
+		if len(err) != 1 || err[0].Code != CodeUnexpectedToken || err[0].Column != test.expectedColumn {
+			t.Fatalf("tests[%d] - expected %s at column %d, but got %q", i, CodeUnexpectedToken, test.expectedColumn, err)
+		}
+	}
+
+	if _, err := New(lexer.New("[1,\t2,\r\n 3]"), ParserOptions{Strict: true}).Parse(); err != nil {
+		t.Fatalf("Strict parser rejected RFC 8259 whitespace. Error: %q", err)
+	}
+}
+
+func TestParserErrorsSupportErrorsIsAndAs(t *testing.T) {
+	_, parserErrors := New(lexer.New(`[1, 2,]`), ParserOptions{Strict: true}).Parse()
+	if parserErrors == nil {
+		t.Fatalf("Parser did not return an error")
+	}
+
+	var err error = parserErrors
+	if errors.Is(err, CodeTrailingComma) == false {
+		t.Fatalf("errors.Is did not match the trailing comma code, got %q", err)
+	}
+
+	if errors.Is(err, CodeMissingComma) {
+		t.Fatalf("errors.Is matched an unrelated code")
+	}
+
+	var syntaxError *SyntaxError
+	if errors.As(err, &syntaxError) == false {
+		t.Fatalf("errors.As did not find a syntax error")
+	}
+
+	if syntaxError.Line != 1 || syntaxError.Column != 6 || syntaxError.Offset != 5 || syntaxError.Literal != "," {
+		t.Fatalf("Unexpected syntax error %+v", syntaxError)
+	}
+}
+
+func TestParserLexerErrorsCarryCodes(t *testing.T) {
+	_, err := New(lexer.New(`["a\qb"]`)).Parse()
+	if len(err) != 1 {
+		t.Fatalf("Expected exactly one error, but got %q", err)
+	}
+
+	if errors.Is(err[0], CodeInvalidEscape) == false || err[0].Offset != 3 || err[0].Literal != "a\uFFFDb" {
+		t.Fatalf("Unexpected syntax error %+v", err[0])
+	}
+}
+
+func TestSyntaxErrorMarshalsToJson(t *testing.T) {
+	_, err := New(lexer.New(`{"a" 1}`)).Parse()
+
+	encoded, marshalErr := json.Marshal(err[0])
+	if marshalErr != nil {
+		t.Fatalf("Marshalling failed: %s", marshalErr)
+	}
+
+	expected := `{"code":"missing_colon","message":"Key value has to be followed by a colon, but got NUMBER","line":1,"column":6,"offset":5,"end_line":1,"end_column":7,"end_offset":6,"literal":"1"}`
+	if string(encoded) != expected {
+		t.Fatalf("Unexpected JSON. Expected %s, but got %s", expected, encoded)
+	}
+}
+
+func TestWriteErrorsIsOnlyColoredOnTerminals(t *testing.T) {
+	_, err := New(lexer.New(`{first_name: "Joe"}`)).Parse()
+
+	var buffer bytes.Buffer
+	if writeErr := WriteErrors(&buffer, err); writeErr != nil {
+		t.Fatalf("Writing errors failed: %s", writeErr)
+	}
+
+	expected := "PARSER ERROR: line 1 and column 2 near token literal 'first_name'.\nKey value has to be of type string. Did you add quotation marks around the key value?\n"
+	if buffer.String() != expected {
+		t.Fatalf("Unexpected output. Expected %q, but got %q", expected, buffer.String())
+	}
+
+	if colored := FormatError(err[0], true); strings.HasPrefix(colored, ANSI_RED) == false {
+		t.Fatalf("Colored error does not start with a color code, got %q", colored)
+	}
+}
+
+func TestErrorRendererShowsSourceSnippet(t *testing.T) {
+	input := "{\n    \"first_name\": \"Joe\",\n    last_name: \"Doe\",\n    \"age\": 88\n}"
+
+	_, err := New(lexer.New(input)).Parse()
+	if len(err) != 1 {
+		t.Fatalf("Expected exactly one error, but got %q", err)
+	}
+
+	expected := `PARSER ERROR: Key value has to be of type string. Did you add quotation marks around the key value?
+ --> line 3, column 5
+  |
+1 | {
+2 |     "first_name": "Joe",
+3 |     last_name: "Doe",
+  |     ^^^^^^^^^
+4 |     "age": 88
+5 | }`
+
+	rendered := NewErrorRenderer(input).Render(err[0])
+	if rendered != expected {
+		t.Fatalf("Unexpected rendering. Expected\n%s\nbut got\n%s", expected, rendered)
+	}
+}
+
+func TestErrorRendererPointsAtLexerErrors(t *testing.T) {
+	input := "[\"a\\qb\",\n\t\"c\" \"d\"]"
+
+	renderer := NewErrorRenderer(input)
+	renderer.ContextLines = 0
+
+	_, err := New(lexer.New(input)).Parse()
+	expected := "PARSER ERROR: Invalid escape sequence '\\q' inside of a string.\n --> line 1, column 4\n  |\n1 | [\"a\\qb\",\n  |    ^^"
+	if rendered := renderer.Render(err[0]); rendered != expected {
+		t.Fatalf("Unexpected rendering. Expected\n%q\nbut got\n%q", expected, rendered)
+	}
+
+	input = "[\n\t1 2]"
+	renderer = NewErrorRenderer(input)
+	renderer.ContextLines = 0
+
+	_, err = New(lexer.New(input)).Parse()
+	expected = "PARSER ERROR: Expected ',' or ']', but got '2' instead.\n --> line 2, column 4\n  |\n2 | \t1 2]\n  | \t  ^"
+	if rendered := renderer.Render(err[0]); rendered != expected {
+		t.Fatalf("Unexpected rendering. Expected\n%q\nbut got\n%q", expected, rendered)
+	}
+}
+
+func TestParserResultTypedAccessors(t *testing.T) {
+	input := `{"name": "Joe", "age": 88, "salary": 99.78, "active": true, "orders": [{"name": "book", "count": 2.0}], "first name": "J"}`
+
+	parserResult, err := New(lexer.New(input)).Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+
+	if name, err := parserResult.GetString("orders", 0, "name"); err != nil || name != "book" {
+		t.Fatalf("GetString returned %q, %v", name, err)
+	}
+
+	if age, err := parserResult.GetInt("age"); err != nil || age != 88 {
+		t.Fatalf("GetInt returned %d, %v", age, err)
+	}
+
+	if count, err := parserResult.GetInt("orders", 0, "count"); err != nil || count != 2 {
+		t.Fatalf("GetInt returned %d, %v", count, err)
+	}
+
+	if salary, err := parserResult.GetFloat("salary"); err != nil || salary != 99.78 {
+		t.Fatalf("GetFloat returned %f, %v", salary, err)
+	}
+
+	if age, err := parserResult.GetFloat("age"); err != nil || age != 88 {
+		t.Fatalf("GetFloat returned %f, %v", age, err)
+	}
+
+	if active, err := parserResult.GetBool("active"); err != nil || active != true {
+		t.Fatalf("GetBool returned %t, %v", active, err)
+	}
+
+	if orders, err := parserResult.GetArray("orders"); err != nil || len(orders) != 1 {
+		t.Fatalf("GetArray returned %v, %v", orders, err)
+	}
+
+	if order, err := parserResult.GetObject("orders", 0); err != nil || order["name"] != "book" {
+		t.Fatalf("GetObject returned %v, %v", order, err)
+	}
+
+	if name, err := parserResult.GetString("first name"); err != nil || name != "J" {
+		t.Fatalf("GetString returned %q, %v", name, err)
+	}
+}
+
+func TestParserResultAccessorErrors(t *testing.T) {
+	input := `{"name": "Joe", "salary": 99.78, "orders": [{"name": "book"}], "car": null}`
+
+	parserResult, err := New(lexer.New(input)).Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+
+	tests := []struct {
+		lookup          func() error
+		expectedSegment int
+		expectedError   string
+	}{
+		{func() error { _, err := parserResult.GetString("orders", 0, "title"); return err }, 2, `cannot get $.orders[0].title: key "title" not found at $.orders[0]`},
+		{func() error { _, err := parserResult.GetString("orders", 3, "name"); return err }, 1, `cannot get $.orders[3].name: index 3 out of range for an array of length 1 at $.orders`},
+		{func() error { _, err := parserResult.GetString("name", "first"); return err }, 1, `cannot get $.name.first: expected an object to look up key "first", but got string at $.name`},
+		{func() error { _, err := parserResult.GetInt("salary"); return err }, 1, `cannot get $.salary: expected integer, but got number 99.78 at $.salary`},
+		{func() error { _, err := parserResult.GetObject("car"); return err }, 1, `cannot get $.car: expected object, but got null at $.car`},
+		{func() error { _, err := parserResult.GetBool(1.5); return err }, 0, `cannot get $[1.5]: path segment 1.5 has to be either a string or an int at $`},
+	}
+
+	for i, test := range tests {
+		err := test.lookup()
+
+		var pathError *PathError
+		if errors.As(err, &pathError) == false {
+			t.Fatalf("tests[%d] - expected a path error, but got %v", i, err)
+		}
+
+		if pathError.Segment != test.expectedSegment {
+			t.Fatalf("tests[%d] - segment is wrong. Expected=%d, but got=%d", i, test.expectedSegment, pathError.Segment)
+		}
+
+		if err.Error() != test.expectedError {
+			t.Fatalf("tests[%d] - error is wrong. Expected=%q, but got=%q", i, test.expectedError, err.Error())
+		}
+	}
+}
+
+func TestParserTracksValuePositions(t *testing.T) {
+	input := "{\n  \"orders\": [\n    {\"name\": \"book\"}\n  ]\n}"
+
+	parserResult, err := New(lexer.New(input), ParserOptions{TrackPositions: true}).Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+
+	tests := []struct {
+		path           []any
+		expectedLine   int
+		expectedColumn int
+	}{
+		{[]any{}, 1, 1},
+		{[]any{"orders"}, 2, 13},
+		{[]any{"orders", 0}, 3, 5},
+		{[]any{"orders", 0, "name"}, 3, 15},
+	}
+
+	for i, test := range tests {
+		position, ok := parserResult.Position(test.path...)
+		if ok == false {
+			t.Fatalf("tests[%d] - position of %s was not tracked", i, FormatPath(test.path))
+		}
+
+		if position.Line != test.expectedLine || position.Column != test.expectedColumn {
+			t.Fatalf("tests[%d] - position is wrong. Expected=%d:%d, but got=%d:%d", i, test.expectedLine, test.expectedColumn, position.Line, position.Column)
+		}
+	}
+
+	if _, ok := parserResult.Position("missing"); ok {
+		t.Fatalf("Position of a missing value was found")
+	}
+}
+
+func TestParserWorksOnReaderLexer(t *testing.T) {
+	input := `{"name": "Joe", "orders": [{"id": 12}, {"id": 13}]}`
+
+	parserResult, err := New(lexer.NewReader(strings.NewReader(input))).Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+
+	if id, err := parserResult.GetInt("orders", 1, "id"); err != nil || id != 13 {
+		t.Fatalf("Unexpected value %d, %v", id, err)
+	}
+}
+
+func TestElementIteratorYieldsElements(t *testing.T) {
+	input := `[{"id": 1}, [2, 3], "four", 5, null,]`
+	expected := []string{`map[id:1]`, `[2 3]`, `four`, `5`, `<nil>`}
+
+	iterator := New(lexer.NewReader(strings.NewReader(input))).Elements()
+	count := 0
+	for iterator.Next() {
+		value, err := iterator.Element()
+		if err != nil {
+			t.Fatalf("tests[%d] - element returned an error. Error: %q", count, err)
+		}
+
+		if iterator.Index() != count {
+			t.Fatalf("tests[%d] - index is wrong. Expected=%d, but got=%d", count, count, iterator.Index())
+		}
+
+		if fmt.Sprint(value) != expected[count] {
+			t.Fatalf("tests[%d] - element is wrong. Expected=%s, but got=%v", count, expected[count], value)
+		}
+		count++
+	}
+
+	if err := iterator.Err(); err != nil {
+		t.Fatalf("Iterator returned an error. Error: %q", err)
+	}
+
+	if count != len(expected) {
+		t.Fatalf("Wrong number of elements. Expected=%d, but got=%d", len(expected), count)
+	}
+}
+
+func TestElementIteratorErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedCount  int
+		expectedCode   ErrorCode
+		expectedColumn int
+	}{
+		{`[]`, 0, "", 0},
+		{`{"a": 1}`, 0, CodeUnexpectedToken, 1},
+		{`[1, 2`, 2, CodeUnclosedArray, 6},
+		{`[1, {"a" 2}, 3]`, 1, CodeMissingColon, 10},
+		{`[1, 2] 3`, 2, CodeTrailingData, 8},
+		{`[1 2]`, 1, CodeMissingComma, 4},
+	}
+
+	for i, test := range tests {
+		iterator := New(lexer.New(test.input)).Elements()
+		count := 0
+		for iterator.Next() {
+			count++
+		}
+
+		if count != test.expectedCount {
+			t.Fatalf("tests[%d] - wrong number of elements. Expected=%d, but got=%d", i, test.expectedCount, count)
+		}
+
+		err := iterator.Err()
+		if test.expectedCode == "" {
+			if err != nil {
+				t.Fatalf("tests[%d] - iterator returned an error. Error: %q", i, err)
+			}
+			continue
+		}
+
+		if len(err) != 1 || err[0].Code != test.expectedCode || err[0].Column != test.expectedColumn {
+			t.Fatalf("tests[%d] - wrong error. Expected=%s at column %d, but got=%v", i, test.expectedCode, test.expectedColumn, err)
+		}
+	}
+}
+
+func TestElementIteratorSkipsInvalidElements(t *testing.T) {
+	input := `[1, {"a": [1, 2 3]}, 01, tru, [4], {"b" 5}]`
+
+	iterator := New(lexer.New(input)).Elements()
+	iterator.SkipInvalid = true
+
+	values := []any{}
+	codes := []ErrorCode{}
+	columns := []int{}
+	for iterator.Next() {
+		value, err := iterator.Element()
+		if err != nil {
+			codes = append(codes, err[0].Code)
+			columns = append(columns, err[0].Column)
+			continue
+		}
+		values = append(values, value)
+	}
+
+	if err := iterator.Err(); err != nil {
+		t.Fatalf("Iterator returned an error. Error: %q", err)
+	}
+
+	if fmt.Sprint(values) != "[1 [4]]" {
+		t.Fatalf("Wrong valid elements. Expected=[1 [4]], but got=%v", values)
+	}
+
+	expectedCodes := []ErrorCode{CodeMissingComma, CodeInvalidNumber, CodeUnexpectedToken, CodeMissingColon}
+	expectedColumns := []int{17, 22, 26, 41}
+	if fmt.Sprint(codes) != fmt.Sprint(expectedCodes) || fmt.Sprint(columns) != fmt.Sprint(expectedColumns) {
+		t.Fatalf("Wrong element errors. Expected=%v at %v, but got=%v at %v", expectedCodes, expectedColumns, codes, columns)
+	}
+}
+
+func TestElementIteratorSkipsToTheEndOfTheInput(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedValues string
+		expectedCodes  string
+		expectedErr    ErrorCode
+		expectedColumn int
+	}{
+		{`[1, {"a": x`, "[1]", "[unexpected_token]", CodeUnclosedArray, 12},
+		{`[1, [2 3`, "[1]", "[missing_comma]", CodeUnclosedArray, 9},
+		{`[{"a": x, "b": "\q", 01}, 2]`, "[2]", "[unexpected_token]", "", 0},
+	}
+
+	for i, test := range tests {
+		iterator := New(lexer.New(test.input)).Elements()
+		iterator.SkipInvalid = true
+
+		values := []any{}
+		codes := []ErrorCode{}
+		for iterator.Next() {
+			value, err := iterator.Element()
+			if err != nil {
+				for _, syntaxError := range err {
+					codes = append(codes, syntaxError.Code)
+				}
+				continue
+			}
+			values = append(values, value)
+		}
+
+		if fmt.Sprint(values) != test.expectedValues || fmt.Sprint(codes) != test.expectedCodes {
+			t.Fatalf("tests[%d] - wrong elements. Expected=%s and %s, but got=%v and %v", i, test.expectedValues, test.expectedCodes, values, codes)
+		}
+
+		err := iterator.Err()
+		if test.expectedErr == "" {
+			if err != nil {
+				t.Fatalf("tests[%d] - iterator returned an error. Error: %q", i, err)
+			}
+			continue
+		}
+
+		if len(err) != 1 || err[0].Code != test.expectedErr || err[0].Column != test.expectedColumn {
+			t.Fatalf("tests[%d] - wrong error. Expected=%s at column %d, but got=%v", i, test.expectedErr, test.expectedColumn, err)
+		}
+	}
+}
+
+func decodeEvents(input string, options ...ParserOptions) ([]string, error) {
+	decoder := NewDecoder(lexer.New(input), options...)
+	events := []string{}
+	for {
+		event, err := decoder.Token()
+		if err == io.EOF {
+			return events, nil
+		}
+
+		if err != nil {
+			return events, err
+		}
+
+		description := fmt.Sprintf("%s@%d", event.Type, event.Depth)
+		switch event.Type {
+		case Key:
+			description = fmt.Sprintf("%s(%s)@%d", event.Type, event.Key, event.Depth)
+		case Value:
+			description = fmt.Sprintf("%s(%v)@%d", event.Type, event.Value, event.Depth)
+		}
+		events = append(events, description)
+	}
+}
+
+func TestDecoderEvents(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"text"`, `Value(text)@0`},
+		{`[]`, `BeginArray@0 EndArray@0`},
+		{`{}`, `BeginObject@0 EndObject@0`},
+		{`[1, 2.5, true, null,]`, `BeginArray@0 Value(1)@1 Value(2.5)@1 Value(true)@1 Value(<nil>)@1 EndArray@0`},
+		{
+			`{"name": "Joe", "orders": [{"id": 1}, []], "empty": {},}`,
+			`BeginObject@0 Key(name)@1 Value(Joe)@1 Key(orders)@1 BeginArray@1 BeginObject@2 Key(id)@3 Value(1)@3 EndObject@2 BeginArray@2 EndArray@2 EndArray@1 Key(empty)@1 BeginObject@1 EndObject@1 EndObject@0`,
+		},
+	}
+
+	for i, test := range tests {
+		events, err := decodeEvents(test.input)
+		if err != nil {
+			t.Fatalf("tests[%d] - decoder returned an error. Error: %q", i, err)
+		}
+
+		if strings.Join(events, " ") != test.expected {
+			t.Fatalf("tests[%d] - events are wrong. Expected=%s, but got=%s", i, test.expected, strings.Join(events, " "))
+		}
+	}
+}
+
+func TestDecoderEventPositions(t *testing.T) {
+	decoder := NewDecoder(lexer.New("{\n  \"ids\": [7]\n}"))
+
+	expected := []struct {
+		eventType EventType
+		line      int
+		column    int
+	}{
+		{BeginObject, 1, 1},
+		{Key, 2, 4},
+		{BeginArray, 2, 10},
+		{Value, 2, 11},
+		{EndArray, 2, 12},
+		{EndObject, 3, 1},
+	}
+
+	for i, test := range expected {
+		event, err := decoder.Token()
+		if err != nil {
+			t.Fatalf("tests[%d] - decoder returned an error. Error: %q", i, err)
+		}
+
+		if event.Type != test.eventType || event.Token.Line != test.line || event.Token.Column != test.column {
+			t.Fatalf("tests[%d] - event is wrong. Expected=%s at %d:%d, but got=%s at %d:%d", i, test.eventType, test.line, test.column, event.Type, event.Token.Line, event.Token.Column)
+		}
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		t.Fatalf("Expected io.EOF after the last event, but got=%v", err)
+	}
+}
+
+func TestDecoderReportsSameErrorsAsParser(t *testing.T) {
+	inputs := []string{
+		`{"name": "Joe", "orders": [{"id": 1, "price": 2.5e3}, [true, null]], "tag": "x"}`,
+		`{first_name: "Joe"}`,
+		`{"a" 1}`,
+		`[1 2]`,
+		`{"a": 1 "b": 2}`,
+		`[1, , 2]`,
+		`[01]`,
+		`{"a": 1} {"b": 2}`,
+		`[1, 2,]`,
+		`{"a": "\x"}`,
+		`]`,
+		``,
+	}
+
+	for i, input := range inputs {
+		// every truncation point of the inputs has to be reported the same way
+		for end := 0; end <= len(input); end++ {
+			for _, options := range []ParserOptions{{}, {Strict: true}} {
+				_, parserErrors := New(lexer.New(input[:end]), options).Parse()
+				_, err := decodeEvents(input[:end], options)
+
+				if parserErrors == nil {
+					if err != nil {
+						t.Fatalf("tests[%d] - decoder returned an error for %q. Error: %q", i, input[:end], err)
+					}
+					continue
+				}
+
+				var syntaxError *SyntaxError
+				if errors.As(err, &syntaxError) == false {
+					t.Fatalf("tests[%d] - decoder did not report %q for %q, got=%v", i, parserErrors, input[:end], err)
+				}
+
+				if syntaxError.Code != parserErrors[0].Code || syntaxError.Offset != parserErrors[0].Offset || syntaxError.Message != parserErrors[0].Message {
+					t.Fatalf("tests[%d] - decoder error for %q is wrong. Expected=%q, but got=%q", i, input[:end], parserErrors[0], syntaxError)
+				}
+			}
+		}
+	}
+}
+
+func TestDecoderIgnoresTrackPositions(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      string
+		expectedError ErrorCode
+	}{
+		{`[true, false, null]`, `BeginArray@0 Value(true)@1 Value(false)@1 Value(<nil>)@1 EndArray@0`, ""},
+		{`{"a": null}`, `BeginObject@0 Key(a)@1 Value(<nil>)@1 EndObject@0`, ""},
+		{`[1, x]`, `BeginArray@0 Value(1)@1`, CodeUnexpectedToken},
+		{`{"a": `, `BeginObject@0 Key(a)@1`, CodeUnexpectedEOF},
+	}
+
+	for i, test := range tests {
+		events, err := decodeEvents(test.input, ParserOptions{TrackPositions: true})
+		if strings.Join(events, " ") != test.expected {
+			t.Fatalf("tests[%d] - events are wrong. Expected=%s, but got=%s", i, test.expected, strings.Join(events, " "))
+		}
+
+		var syntaxError *SyntaxError
+		if test.expectedError == "" && err != nil {
+			t.Fatalf("tests[%d] - decoder returned an error. Error: %q", i, err)
+		}
+
+		if test.expectedError != "" && (errors.As(err, &syntaxError) == false || syntaxError.Code != test.expectedError) {
+			t.Fatalf("tests[%d] - error is wrong. Expected=%s, but got=%v", i, test.expectedError, err)
+		}
+	}
+}
+
+func TestDecoderReadsConcatenatedValues(t *testing.T) {
+	events, err := decodeEvents(`{"id": 1} [2] 3`, ParserOptions{AllowTrailingData: true})
+	if err != nil {
+		t.Fatalf("Decoder returned an error. Error: %q", err)
+	}
+
+	expected := `BeginObject@0 Key(id)@1 Value(1)@1 EndObject@0 BeginArray@0 Value(2)@1 EndArray@0 Value(3)@0`
+	if strings.Join(events, " ") != expected {
+		t.Fatalf("Events are wrong. Expected=%s, but got=%s", expected, strings.Join(events, " "))
+	}
+}
+
+func TestDecoderLimitsNodesPerValue(t *testing.T) {
+	options := ParserOptions{AllowTrailingData: true, Limits: ParserLimits{MaxNodes: 2}}
+
+	events, err := decodeEvents(`true true true true [1] false`, options)
+	if err != nil {
+		t.Fatalf("Decoder returned an error. Error: %q", err)
+	}
+
+	expected := `Value(true)@0 Value(true)@0 Value(true)@0 Value(true)@0 BeginArray@0 Value(1)@1 EndArray@0 Value(false)@0`
+	if strings.Join(events, " ") != expected {
+		t.Fatalf("Events are wrong. Expected=%s, but got=%s", expected, strings.Join(events, " "))
+	}
+
+	var syntaxError *SyntaxError
+	_, err = decodeEvents(`true [1, 2] false`, options)
+	if errors.As(err, &syntaxError) == false || syntaxError.Code != CodeTooManyNodes || syntaxError.Column != 10 {
+		t.Fatalf("Expected %s at column 10, but got=%v", CodeTooManyNodes, err)
+	}
+}
+
+func TestParserLimitsNestingDepth(t *testing.T) {
+	tests := []struct {
+		input          string
+		options        ParserOptions
+		expectedColumn int
+	}{
+		{strings.Repeat("[", 1000) + strings.Repeat("]", 1000), ParserOptions{}, 0},
+		{strings.Repeat("[", 1001) + strings.Repeat("]", 1001), ParserOptions{}, 1001},
+		{strings.Repeat("[", 500000), ParserOptions{}, 1001},
+		{strings.Repeat(`{"a":`, 500000), ParserOptions{}, 5001},
+		{`{"a": [{"b": 1}]}`, ParserOptions{MaxDepth: 3}, 0},
+		{`{"a": [{"b": []}]}`, ParserOptions{MaxDepth: 3}, 14},
+		{strings.Repeat("[", 5000) + strings.Repeat("]", 5000), ParserOptions{MaxDepth: -1}, 0},
+	}
+
+	for i, test := range tests {
+		_, parserErrors := New(lexer.New(test.input), test.options).Parse()
+		_, decoderErr := decodeEvents(test.input, test.options)
+
+		if test.expectedColumn == 0 {
+			if parserErrors != nil || decoderErr != nil {
+				t.Fatalf("tests[%d] - deep input was rejected. Errors: %q, %q", i, parserErrors, decoderErr)
+			}
+			continue
+		}
+
+		var decoderError *SyntaxError
+		errors.As(decoderErr, &decoderError)
+		for _, err := range []*SyntaxError{parserErrors[0], decoderError} {
+			if err == nil || err.Code != CodeMaxDepth || err.Column != test.expectedColumn {
+				t.Fatalf("tests[%d] - expected %s at column %d, but got=%v", i, CodeMaxDepth, test.expectedColumn, err)
+			}
+		}
+	}
+}
+
+func TestElementIteratorLimitsNestingDepth(t *testing.T) {
+	input := "[1, " + strings.Repeat("[", 10) + strings.Repeat("]", 10) + ", 2]"
+
+	iterator := New(lexer.New(input), ParserOptions{MaxDepth: 5}).Elements()
+	iterator.SkipInvalid = true
+
+	values := []any{}
+	var elementErrors ParserErrors
+	for iterator.Next() {
+		value, err := iterator.Element()
+		if err != nil {
+			elementErrors = append(elementErrors, err...)
+			continue
+		}
+		values = append(values, value)
+	}
+
+	if fmt.Sprint(values) != "[1 2]" || iterator.Err() != nil {
+		t.Fatalf("Wrong elements. Expected=[1 2], but got=%v (error %v)", values, iterator.Err())
+	}
+
+	if len(elementErrors) != 1 || elementErrors[0].Code != CodeMaxDepth || elementErrors[0].Column != 9 {
+		t.Fatalf("Wrong element errors. Got=%v", elementErrors)
+	}
+}
+
+func TestParserLimits(t *testing.T) {
+	input := `{"name": "Joe", "tags": ["a", "b", "c"], "age": 88}`
+
+	tests := []struct {
+		limits         ParserLimits
+		expectedCode   ErrorCode
+		expectedColumn int
+	}{
+		{ParserLimits{MaxInputSize: 51, MaxStringLength: 4, MaxNumberLength: 2, MaxMembers: 3, MaxArrayLength: 3, MaxNodes: 8}, "", 0},
+		{ParserLimits{MaxInputSize: 50}, CodeInputTooLarge, 51},
+		{ParserLimits{MaxStringLength: 3}, CodeStringTooLong, 2},
+		{ParserLimits{MaxNumberLength: 1}, CodeNumberTooLong, 49},
+		{ParserLimits{MaxMembers: 2}, CodeTooManyMembers, 43},
+		{ParserLimits{MaxArrayLength: 2}, CodeArrayTooLong, 37},
+		{ParserLimits{MaxNodes: 6}, CodeTooManyNodes, 49},
+	}
+
+	for i, test := range tests {
+		options := ParserOptions{Limits: test.limits}
+		_, parserErrors := New(lexer.New(input), options).Parse()
+		_, decoderErr := decodeEvents(input, options)
+
+		if test.expectedCode == "" {
+			if parserErrors != nil || decoderErr != nil {
+				t.Fatalf("tests[%d] - input within the limits was rejected. Errors: %q, %q", i, parserErrors, decoderErr)
+			}
+			continue
+		}
+
+		if len(parserErrors) == 0 {
+			t.Fatalf("tests[%d] - input over the limit was accepted", i)
+		}
+
+		var decoderError *SyntaxError
+		errors.As(decoderErr, &decoderError)
+		for _, err := range []*SyntaxError{parserErrors[0], decoderError} {
+			if err == nil || err.Code != test.expectedCode || err.Column != test.expectedColumn {
+				t.Fatalf("tests[%d] - expected %s at column %d, but got=%v", i, test.expectedCode, test.expectedColumn, err)
+			}
+		}
+	}
+}
+
+func TestElementIteratorLimits(t *testing.T) {
+	input := `[[1, 2], [3, 4, 5], [6], 7]`
+
+	iterator := New(lexer.New(input), ParserOptions{Limits: ParserLimits{MaxNodes: 3, MaxArrayLength: 3}}).Elements()
+	iterator.SkipInvalid = true
+
+	values := []any{}
+	codes := []ErrorCode{}
+	for iterator.Next() {
+		value, err := iterator.Element()
+		if err != nil {
+			codes = append(codes, err[0].Code)
+			continue
+		}
+		values = append(values, value)
+	}
+
+	// the nodes are counted per element, the top-level array is limited too
+	if fmt.Sprint(values) != "[[1 2] [6]]" || fmt.Sprint(codes) != "[too_many_nodes]" {
+		t.Fatalf("Wrong elements. Got=%v with errors %v", values, codes)
+	}
+
+	err := iterator.Err()
+	if len(err) != 1 || err[0].Code != CodeArrayTooLong || err[0].Column != 26 {
+		t.Fatalf("Expected %s at column 26, but got=%v", CodeArrayTooLong, err)
+	}
+}
+
+func TestParserDuplicateKeys(t *testing.T) {
+	input := `{"id": 1, "name": "Joe", "id": [2], "nested": {"id": 4, "id": 5}, "id": 3}`
+
+	tests := []struct {
+		policy   DuplicateKeyPolicy
+		expected string
+	}{
+		{DuplicateKeysLastWins, `map[id:3 name:Joe nested:map[id:5]]`},
+		{DuplicateKeysFirstWins, `map[id:1 name:Joe nested:map[id:4]]`},
+		{DuplicateKeysCollect, `map[id:[1 [2] 3] name:Joe nested:map[id:[4 5]]]`},
+	}
+
+	for i, test := range tests {
+		parserResult, err := New(lexer.New(input), ParserOptions{DuplicateKeys: test.policy}).Parse()
+		if err != nil {
+			t.Fatalf("tests[%d] - Parser returned an error. Error: %q", i, err)
+		}
+
+		if fmt.Sprint(parserResult.Value) != test.expected {
+			t.Fatalf("tests[%d] - value is wrong. Expected=%s, but got=%v", i, test.expected, parserResult.Value)
+		}
+
+		if _, ok := parserResult.Value.(map[string]any); ok == false {
+			t.Fatalf("tests[%d] - expected a map[string]any, but got=%T", i, parserResult.Value)
+		}
+
+		// ordered objects apply the policies the same way
+		orderedResult, err := New(lexer.New(input), ParserOptions{DuplicateKeys: test.policy, OrderedObjects: true}).Parse()
+		if err != nil {
+			t.Fatalf("tests[%d] - Parser returned an error. Error: %q", i, err)
+		}
+
+		if fmt.Sprint(orderedToMap(orderedResult.Value)) != test.expected {
+			t.Fatalf("tests[%d] - ordered value is wrong. Expected=%s, but got=%v", i, test.expected, orderedResult.Value)
+		}
+	}
+}
+
+// orderedToMap turns ordered objects back into maps, so that they are
+// printed by fmt like the maps of the parser.
+func orderedToMap(value any) any {
+	switch val := value.(type) {
+	case *OrderedObject:
+		result := map[string]any{}
+		for _, key := range val.Keys() {
+			member, _ := val.Get(key)
+			result[key] = orderedToMap(member)
+		}
+
+		return result
+	case []any:
+		for idx := range val {
+			val[idx] = orderedToMap(val[idx])
+		}
+	}
+
+	return value
+}
+
+func TestParserReportsDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLine    int
+		expectedColumn  int
+		expectedMessage string
+	}{
+		{`{"id": 1, "id": 2}`, 1, 12, `Duplicate key "id", it was already defined at line 1 column 3.`},
+		{"{\n  \"a\": {\"id\": 1},\n  \"b\": {\"id\": 2, \"x\": 3, \"id\": 4}\n}", 3, 27, `Duplicate key "id", it was already defined at line 3 column 10.`},
+		{`[{"id": 1}, {"id": 2}]`, 0, 0, ""},
+	}
+
+	for i, test := range tests {
+		options := ParserOptions{DuplicateKeys: DuplicateKeysError}
+		_, parserErrors := New(lexer.New(test.input), options).Parse()
+		_, decoderErr := decodeEvents(test.input, options)
+
+		if test.expectedLine == 0 {
+			if parserErrors != nil || decoderErr != nil {
+				t.Fatalf("tests[%d] - keys of different objects were reported. Errors: %q, %q", i, parserErrors, decoderErr)
+			}
+			continue
+		}
+
+		if len(parserErrors) == 0 {
+			t.Fatalf("tests[%d] - duplicate key was not reported", i)
+		}
+
+		var decoderError *SyntaxError
+		errors.As(decoderErr, &decoderError)
+		for _, err := range []*SyntaxError{parserErrors[0], decoderError} {
+			if err == nil || err.Code != CodeDuplicateKey || err.Line != test.expectedLine || err.Column != test.expectedColumn || err.Message != test.expectedMessage {
+				t.Fatalf("tests[%d] - expected %q at %d:%d, but got=%v", i, test.expectedMessage, test.expectedLine, test.expectedColumn, err)
+			}
+		}
+	}
+}
+
+func TestParserOrderedObjects(t *testing.T) {
+	input := `{"zeta": 1, "alpha": {"y": true, "x": null}, "mid": [{"b": 1, "a": 2}], "zeta": 3}`
+
+	parserResult, err := New(lexer.New(input), ParserOptions{OrderedObjects: true}).Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+
+	if parserResult.Kind() != KindObject || parserResult.IsSingleMap() {
+		t.Fatalf("Unexpected result kind %s", parserResult.Kind())
+	}
+
+	// a duplicate keeps the place of the first occurrence
+	expected := `{zeta:3 alpha:{y:true x:<nil>} mid:[{b:1 a:2}]}`
+	if fmt.Sprint(parserResult.Value) != expected {
+		t.Fatalf("Value is wrong. Expected=%s, but got=%v", expected, parserResult.Value)
+	}
+
+	object, err2 := parserResult.GetOrderedObject("mid", 0)
+	if err2 != nil || fmt.Sprint(object.Keys()) != "[b a]" {
+		t.Fatalf("Unexpected ordered object %v, %v", object, err2)
+	}
+
+	if number, err := parserResult.GetInt("mid", 0, "a"); err != nil || number != 2 {
+		t.Fatalf("Unexpected value %d, %v", number, err)
+	}
+
+	if members, err := parserResult.GetObject("alpha"); err != nil || len(members) != 2 {
+		t.Fatalf("Unexpected members %v, %v", members, err)
+	}
+
+	plainResult, _ := New(lexer.New(input)).Parse()
+	if _, err := plainResult.GetOrderedObject("alpha"); err == nil {
+		t.Fatalf("Expected an error for an object parsed without the OrderedObjects option")
+	}
+}
+
+func TestOrderedObject(t *testing.T) {
+	var object OrderedObject
+	object.Set("c", 1)
+	object.Set("a", 2)
+	object.Set("b", 3)
+	object.Set("c", 4)
+	object.Delete("a")
+	object.Delete("missing")
+
+	if fmt.Sprint(&object) != "{c:4 b:3}" || object.Len() != 2 {
+		t.Fatalf("Object is wrong. Expected={c:4 b:3}, but got=%v", &object)
+	}
+
+	if value, ok := object.Get("b"); ok == false || value != 3 {
+		t.Fatalf("Unexpected value %v", value)
+	}
+
+	if _, ok := object.Get("a"); ok {
+		t.Fatalf("Deleted key was found")
+	}
+}
+
+func TestParserUseNumber(t *testing.T) {
+	input := `{"id": 1234567890123456789012, "amount": 1.10, "small": -5, "exp": 2.5e3}`
+
+	parserResult, err := New(lexer.New(input), ParserOptions{UseNumber: true}).Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+
+	expected := map[string]Number{"id": "1234567890123456789012", "amount": "1.10", "small": "-5", "exp": "2.5e3"}
+	for key, expectedNumber := range expected {
+		number, err := parserResult.GetNumber(key)
+		if err != nil || number != expectedNumber {
+			t.Fatalf("Number %s is wrong. Expected=%s, but got=%s (error %v)", key, expectedNumber, number, err)
+		}
+	}
+
+	if small, err := parserResult.GetInt("small"); err != nil || small != -5 {
+		t.Fatalf("Unexpected int %d, %v", small, err)
+	}
+
+	if exp, err := parserResult.GetInt("exp"); err != nil || exp != 2500 {
+		t.Fatalf("Unexpected int %d, %v", exp, err)
+	}
+
+	if amount, err := parserResult.GetFloat("amount"); err != nil || amount != 1.1 {
+		t.Fatalf("Unexpected float %g, %v", amount, err)
+	}
+
+	if _, err := parserResult.GetInt("id"); err == nil {
+		t.Fatalf("Expected an error for an integer overflowing int")
+	}
+}
+
+func TestNumberConversions(t *testing.T) {
+	tests := []struct {
+		number           Number
+		expectedInt64    string
+		expectedUint64   string
+		expectedFloat64  string
+		expectedBigInt   string
+		expectedBigFloat string
+	}{
+		{"42", "42", "42", "42", "42", "42"},
+		{"-7", "-7", "error", "-7", "-7", "-7"},
+		{"18446744073709551615", "error", "18446744073709551615", "1.8446744073709552e+19", "18446744073709551615", "18446744073709551615"},
+		{"123456789012345678901234567890", "error", "error", "1.2345678901234568e+29", "123456789012345678901234567890", "123456789012345678901234567890"},
+		{"1.10", "error", "error", "1.1", "error", "1.1"},
+		{"1.5e3", "error", "error", "1500", "1500", "1500"},
+		{"0.1000000000000000000000000001", "error", "error", "0.1", "error", "0.1000000000000000000000000001"},
+	}
+
+	format := func(value any, err error) string {
+		if err != nil {
+			return "error"
+		}
+
+		if float, ok := value.(*big.Float); ok {
+			return float.Text('f', -1)
+		}
+
+		return fmt.Sprint(value)
+	}
+
+	for i, test := range tests {
+		results := []string{
+			format(test.number.Int64()),
+			format(test.number.Uint64()),
+			format(test.number.Float64()),
+			format(test.number.BigInt()),
+			format(test.number.BigFloat()),
+		}
+		expected := []string{test.expectedInt64, test.expectedUint64, test.expectedFloat64, test.expectedBigInt, test.expectedBigFloat}
+
+		if fmt.Sprint(results) != fmt.Sprint(expected) {
+			t.Fatalf("tests[%d] - conversions of %s are wrong. Expected=%v, but got=%v", i, test.number, expected, results)
+		}
+	}
+}
+
+func TestParserBigNumbers(t *testing.T) {
+	input := `[42, 123456789012345678901234567890, 0.1, 19.99, 2.5e3, -1e-30, 1.5e400]`
+
+	tests := []struct {
+		options  ParserOptions
+		expected []string
+	}{
+		{ParserOptions{BigNumbers: BigNumbersRat}, []string{"int 42", "*big.Int 123456789012345678901234567890", "*big.Rat 0.1", "*big.Rat 19.99", "*big.Rat 2500", "*big.Rat -0.000000000000000000000000000001", "*big.Rat 15" + strings.Repeat("0", 399)}},
+		{ParserOptions{BigNumbers: BigNumbersFloat}, []string{"int 42", "*big.Int 123456789012345678901234567890", "*big.Float 0.1", "*big.Float 19.99", "*big.Float 2500", "*big.Float -1e-30", "*big.Float 1.5e+400"}},
+		{ParserOptions{BigNumbers: BigNumbersFloat, BigFloatPrecision: 24}, []string{"int 42", "*big.Int 123456789012345678901234567890", "*big.Float 0.1", "*big.Float 19.99", "*big.Float 2500", "*big.Float -1e-30", "*big.Float 1.5e+400"}},
+		{ParserOptions{BigNumbers: BigNumbersRat, UseNumber: true}, []string{"parser.Number 42", "parser.Number 123456789012345678901234567890", "parser.Number 0.1", "parser.Number 19.99", "parser.Number 2.5e3", "parser.Number -1e-30", "parser.Number 1.5e400"}},
+	}
+
+	for i, test := range tests {
+		parserResult, err := New(lexer.New(input), test.options).Parse()
+		if err != nil {
+			t.Fatalf("tests[%d] - Parser returned an error. Error: %q", i, err)
+		}
+
+		array := parserResult.Value.([]any)
+		for idx, value := range array {
+			literal, err := FormatNumber(value)
+			if err != nil {
+				t.Fatalf("tests[%d] - FormatNumber returned an error. Error: %q", i, err)
+			}
+
+			if got := fmt.Sprintf("%T %s", value, literal); got != test.expected[idx] {
+				t.Fatalf("tests[%d] - value %d is wrong. Expected=%s, but got=%s", i, idx, test.expected[idx], got)
+			}
+		}
+	}
+
+	parserResult, _ := New(lexer.New(input), ParserOptions{BigNumbers: BigNumbersFloat, BigFloatPrecision: 24}).Parse()
+	if precision := parserResult.Value.([]any)[2].(*big.Float).Prec(); precision != 24 {
+		t.Fatalf("Precision is wrong. Expected=24, but got=%d", precision)
+	}
+
+	exponentTests := []struct {
+		input   string
+		message string
+	}{
+		{`[1e99999]`, "The exponent of the number is outside of ±10000, which is the range of exact values."},
+		{`[1e-20000]`, "The exponent of the number is outside of ±10000, which is the range of exact values."},
+		{`[-2.5E+10001]`, "The exponent of the number is outside of ±10000, which is the range of exact values."},
+	}
+
+	for i, test := range exponentTests {
+		_, errors := New(lexer.New(test.input), ParserOptions{BigNumbers: BigNumbersRat}).Parse()
+		if len(errors) != 1 || errors[0].Code != CodeInvalidNumber {
+			t.Fatalf("tests[%d] - Expected an invalid_number error, but got=%v", i, errors)
+		}
+
+		if errors[0].Message != test.message {
+			t.Fatalf("tests[%d] - message is wrong. Expected=%q, but got=%q", i, test.message, errors[0].Message)
+		}
+	}
+
+	if _, errors := New(lexer.New(`[1e-10000]`), ParserOptions{BigNumbers: BigNumbersRat}).Parse(); len(errors) != 0 {
+		t.Fatalf("Expected 1e-10000 to be in range, but got=%v", errors)
+	}
+}
+
+func TestBigNumberAccessors(t *testing.T) {
+	input := `{"big": 123456789012345678901234567890, "price": 19.99, "whole": 2.5e3}`
+
+	parserResult, err := New(lexer.New(input), ParserOptions{BigNumbers: BigNumbersRat}).Parse()
+	if err != nil {
+		t.Fatalf("Parser returned an error. Error: %q", err)
+	}
+
+	if whole, err := parserResult.GetInt("whole"); err != nil || whole != 2500 {
+		t.Fatalf("Unexpected int %d, %v", whole, err)
+	}
+
+	if _, err := parserResult.GetInt("big"); err == nil {
+		t.Fatalf("Expected an error for an integer overflowing int")
+	}
+
+	if price, err := parserResult.GetFloat("price"); err != nil || price != 19.99 {
+		t.Fatalf("Unexpected float %g, %v", price, err)
+	}
+
+	if number, err := parserResult.GetNumber("big"); err != nil || number != "123456789012345678901234567890" {
+		t.Fatalf("Unexpected number %s, %v", number, err)
+	}
+}
+
+func TestCompareNumbers(t *testing.T) {
+	tests := []struct {
+		a        any
+		b        any
+		expected string
+	}{
+		{Number("1.10"), big.NewRat(11, 10), "0"},
+		{Number("0.1"), 0.1, "-1"},
+		{1, Number("1e0"), "0"},
+		{new(big.Int).Lsh(big.NewInt(1), 70), Number("1180591620717411303424"), "0"},
+		{big.NewFloat(0.5), Number("0.49999999999999999999"), "1"},
+		{Number("1e99999"), 1, "error"},
+		{"1", 1, "error"},
+	}
+
+	for i, test := range tests {
+		result, err := CompareNumbers(test.a, test.b)
+		got := fmt.Sprint(result)
+		if err != nil {
+			got = "error"
+		}
+
+		if got != test.expected {
+			t.Fatalf("tests[%d] - comparison of %v and %v is wrong. Expected=%s, but got=%s", i, test.a, test.b, test.expected, got)
+		}
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		number   any
+		expected string
+	}{
+		{big.NewRat(1999, 100), "19.99"},
+		{big.NewRat(-1, 8), "-0.125"},
+		{big.NewRat(10, 2), "5"},
+		{big.NewRat(1, 3), "error"},
+		{new(big.Float).SetInf(false), "error"},
+		{big.NewInt(-42), "-42"},
+		{Number("1.10"), "1.10"},
+		{2.5, "2.5"},
+		{math.NaN(), "error"},
+		{true, "error"},
+	}
+
+	for i, test := range tests {
+		literal, err := FormatNumber(test.number)
+		if err != nil {
+			literal = "error"
+		}
+
+		if literal != test.expected {
+			t.Fatalf("tests[%d] - literal is wrong. Expected=%s, but got=%s", i, test.expected, literal)
+		}
+	}
+}
+
+func TestErrorRendererUnderlinesSpans(t *testing.T) {
+	input := "{\"city\": \"😀\" \"zip\": 1}"
+
+	_, err := New(lexer.New(input), ParserOptions{ColumnUnit: lexer.ColumnUTF16}).Parse()
+	if len(err) != 1 || err[0].Column != 16 || err[0].EndColumn != 19 || err[0].Offset != 17 || err[0].EndOffset != 20 {
+		t.Fatalf("Unexpected errors %+v", err)
+	}
+
+	renderer := NewErrorRenderer(input)
+	renderer.ContextLines = 0
+
+	expected := "PARSER ERROR: Expected ',' or '}', but got 'zip' instead.\n --> line 1, column 16\n  |\n1 | {\"city\": \"😀\" \"zip\": 1}\n  |               ^^^"
+	if rendered := renderer.Render(err[0]); rendered != expected {
+		t.Fatalf("Unexpected rendering. Expected\n%q\nbut got\n%q", expected, rendered)
+	}
+
+	input = "[\"Zoë\", 0123]"
+	_, err = New(lexer.New(input), ParserOptions{ColumnUnit: lexer.ColumnCodePoints}).Parse()
+	if len(err) != 1 || err[0].Column != 9 || err[0].EndColumn != 13 {
+		t.Fatalf("Unexpected errors %+v", err)
+	}
+
+	renderer = NewErrorRenderer(input)
+	renderer.ContextLines = 0
+
+	expected = "PARSER ERROR: Leading zeros are not allowed in numbers.\n --> line 1, column 9\n  |\n1 | [\"Zoë\", 0123]\n  |         ^^^^"
+	if rendered := renderer.Render(err[0]); rendered != expected {
+		t.Fatalf("Unexpected rendering. Expected\n%q\nbut got\n%q", expected, rendered)
+	}
+}
+
+func TestParserUTF8AndByteOrderMark(t *testing.T) {
+	tests := []struct {
+		input         string
+		options       ParserOptions
+		expectedValue string
+		expectedCode  ErrorCode
+	}{
+		{"\xef\xbb\xbf{\"name\": \"Zoë\"}", ParserOptions{}, "map[name:Zoë]", ""},
+		{"\xef\xbb\xbf{\"name\": \"Zoë\"}", ParserOptions{Strict: true}, "", CodeUnexpectedBOM},
+		{"[\"Zo\xeb\"]", ParserOptions{}, "", CodeInvalidUTF8},
+		{"[\"Zo\xeb\"]", ParserOptions{ReplaceInvalidUTF8: true}, "[Zo�]", ""},
+		{"[1, \xff]", ParserOptions{}, "", CodeInvalidUTF8},
+		{"[1, \xff]", ParserOptions{ReplaceInvalidUTF8: true}, "", CodeUnexpectedToken},
+		{"[1, \xef\xbb\xbf]", ParserOptions{}, "", CodeUnexpectedToken},
+	}
+
+	for i, test := range tests {
+		parserResult, err := New(lexer.New(test.input), test.options).Parse()
+		if test.expectedCode != "" {
+			if len(err) == 0 || err[0].Code != test.expectedCode {
+				t.Fatalf("tests[%d] - expected %s, but got=%v", i, test.expectedCode, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("tests[%d] - Parser returned an error. Error: %q", i, err)
+		}
+
+		if value := fmt.Sprint(parserResult.Value); value != test.expectedValue {
+			t.Fatalf("tests[%d] - value is wrong. Expected=%s, but got=%s", i, test.expectedValue, value)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PathError is returned by the typed accessors of ParserResult. Path is the
+// full path that was requested and Segment the index of the path segment at
+// which the lookup or the conversion failed.
+type PathError struct {
+	Path    []any
+	Segment int
+	Message string
+}
+
+func (pathError *PathError) Error() string {
+	return fmt.Sprintf("cannot get %s: %s at %s", FormatPath(pathError.Path), pathError.Message, FormatPath(pathError.Path[:pathError.Segment]))
+}
+
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// FormatPath renders a path made of object keys and array indexes, like
+// $.orders[0].name.
+func FormatPath(path []any) string {
+	var builder strings.Builder
+	builder.WriteString("$")
+
+	for _, segment := range path {
+		switch seg := segment.(type) {
+		case int:
+			fmt.Fprintf(&builder, "[%d]", seg)
+		case string:
+			if identifierRegexp.MatchString(seg) {
+				fmt.Fprintf(&builder, ".%s", seg)
+			} else {
+				fmt.Fprintf(&builder, "[%q]", seg)
+			}
+		default:
+			fmt.Fprintf(&builder, "[%v]", seg)
+		}
+	}
+
+	return builder.String()
+}
+
+// Get walks the parsed value following the path, where strings are used as
+// object keys and ints as array indexes.
+func (parserResult *ParserResult) Get(path ...any) (any, error) {
+	current := parserResult.Value
+
+	for idx, segment := range path {
+		switch seg := segment.(type) {
+		case string:
+			var value any
+			wasFound := false
+			switch object := current.(type) {
+			case map[string]any:
+				value, wasFound = object[seg]
+			case *OrderedObject:
+				value, wasFound = object.Get(seg)
+			default:
+				return nil, &PathError{Path: path, Segment: idx, Message: fmt.Sprintf("expected an object to look up key %q, but got %s", seg, kindOf(current))}
+			}
+
+			if wasFound == false {
+				return nil, &PathError{Path: path, Segment: idx, Message: fmt.Sprintf("key %q not found", seg)}
+			}
+			current = value
+		case int:
+			array, ok := current.([]any)
+			if ok == false {
+				return nil, &PathError{Path: path, Segment: idx, Message: fmt.Sprintf("expected an array to look up index %d, but got %s", seg, kindOf(current))}
+			}
+
+			if seg < 0 || seg >= len(array) {
+				return nil, &PathError{Path: path, Segment: idx, Message: fmt.Sprintf("index %d out of range for an array of length %d", seg, len(array))}
+			}
+			current = array[seg]
+		default:
+			return nil, &PathError{Path: path, Segment: idx, Message: fmt.Sprintf("path segment %v has to be either a string or an int", segment)}
+		}
+	}
+
+	return current, nil
+}
+
+func (parserResult *ParserResult) GetString(path ...any) (string, error) {
+	value, err := parserResult.Get(path...)
+	if err != nil {
+		return "", err
+	}
+
+	str, ok := value.(string)
+	if ok == false {
+		return "", conversionError(path, "string", value)
+	}
+
+	return str, nil
+}
+
+// GetInt returns an integer, floats are only accepted when they do not have
+// a fractional part.
+func (parserResult *ParserResult) GetInt(path ...any) (int, error) {
+	value, err := parserResult.Get(path...)
+	if err != nil {
+		return 0, err
+	}
+
+	switch val := value.(type) {
+	case int:
+		return val, nil
+	case float64:
+		if val == math.Trunc(val) && val >= math.MinInt64 && val < math.MaxInt64 {
+			return int(val), nil
+		}
+	case Number:
+		if integer, err := strconv.ParseInt(string(val), 10, strconv.IntSize); err == nil {
+			return int(integer), nil
+		}
+
+		if float, err := val.Float64(); err == nil && float == math.Trunc(float) && float >= math.MinInt64 && float < math.MaxInt64 {
+			return int(float), nil
+		}
+	case *big.Int, *big.Rat, *big.Float:
+		// big numbers only hold values that did not fit into an int, unless
+		// they have a fraction or an exponent that cancels out
+		if rat, err := NumberToRat(val); err == nil && rat.IsInt() && rat.Num().IsInt64() {
+			return int(rat.Num().Int64()), nil
+		}
+	}
+
+	return 0, conversionError(path, "integer", value)
+}
+
+func (parserResult *ParserResult) GetFloat(path ...any) (float64, error) {
+	value, err := parserResult.Get(path...)
+	if err != nil {
+		return 0, err
+	}
+
+	switch val := value.(type) {
+	case int:
+		return float64(val), nil
+	case float64:
+		return val, nil
+	case Number:
+		if float, err := val.Float64(); err == nil {
+			return float, nil
+		}
+	case *big.Int, *big.Rat, *big.Float:
+		if rat, err := NumberToRat(val); err == nil {
+			if float, _ := rat.Float64(); math.IsInf(float, 0) == false {
+				return float, nil
+			}
+		}
+	}
+
+	return 0, conversionError(path, "float", value)
+}
+
+// GetNumber returns a number as a Number, which is lossless for inputs parsed
+// with the UseNumber or the BigNumbers option.
+func (parserResult *ParserResult) GetNumber(path ...any) (Number, error) {
+	value, err := parserResult.Get(path...)
+	if err != nil {
+		return "", err
+	}
+
+	switch val := value.(type) {
+	case Number:
+		return val, nil
+	case int:
+		return Number(strconv.Itoa(val)), nil
+	case float64:
+		return Number(strconv.FormatFloat(val, 'g', -1, 64)), nil
+	case *big.Int, *big.Rat, *big.Float:
+		if literal, err := FormatNumber(val); err == nil {
+			return Number(literal), nil
+		}
+	}
+
+	return "", conversionError(path, "number", value)
+}
+
+func (parserResult *ParserResult) GetBool(path ...any) (bool, error) {
+	value, err := parserResult.Get(path...)
+	if err != nil {
+		return false, err
+	}
+
+	boolean, ok := value.(bool)
+	if ok == false {
+		return false, conversionError(path, "bool", value)
+	}
+
+	return boolean, nil
+}
+
+func (parserResult *ParserResult) GetArray(path ...any) ([]any, error) {
+	value, err := parserResult.Get(path...)
+	if err != nil {
+		return nil, err
+	}
+
+	array, ok := value.([]any)
+	if ok == false {
+		return nil, conversionError(path, "array", value)
+	}
+
+	return array, nil
+}
+
+// GetObject returns the members of an object. The members of an
+// OrderedObject are returned without their order, see GetOrderedObject.
+func (parserResult *ParserResult) GetObject(path ...any) (map[string]any, error) {
+	value, err := parserResult.Get(path...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch object := value.(type) {
+	case map[string]any:
+		return object, nil
+	case *OrderedObject:
+		return object.Map(), nil
+	}
+
+	return nil, conversionError(path, "object", value)
+}
+
+// GetOrderedObject returns an object parsed with the OrderedObjects option.
+func (parserResult *ParserResult) GetOrderedObject(path ...any) (*OrderedObject, error) {
+	value, err := parserResult.Get(path...)
+	if err != nil {
+		return nil, err
+	}
+
+	object, ok := value.(*OrderedObject)
+	if ok == false {
+		return nil, conversionError(path, "ordered object", value)
+	}
+
+	return object, nil
+}
+
+func conversionError(path []any, expected string, value any) *PathError {
+	message := fmt.Sprintf("expected %s, but got %s %v", expected, kindOf(value), value)
+	if value == nil {
+		message = fmt.Sprintf("expected %s, but got null", expected)
+	}
+
+	return &PathError{Path: path, Segment: len(path), Message: message}
+}
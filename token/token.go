@@ -7,10 +7,17 @@ type Token struct {
 	Literal string
 	Line    int
 	Column  int
+	// Offset is the zero-based byte offset of the token in the input
+	Offset int
+	// the position right after the last character of the token, for strings
+	// the span between Offset and EndOffset leaves out the quotes
+	EndLine   int
+	EndColumn int
+	EndOffset int
 }
 
-func New(tokenType TokenType, literal string, line int, column int) *Token {
-	return &Token{Type: tokenType, Literal: literal, Line: line, Column: column}
+func New(tokenType TokenType, literal string, line int, column int, offset int) *Token {
+	return &Token{Type: tokenType, Literal: literal, Line: line, Column: column, Offset: offset}
 }
 
 const (
@@ -22,7 +29,6 @@ const (
 
 	COMMA = ","
 	COLON = ":"
-	MINUS = "-"
 
 	LBRACE        = "{"
 	RBRACE        = "}"